@@ -0,0 +1,283 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/ical"
+)
+
+const (
+	calendarTokenTTL  = 5 * 365 * 24 * time.Hour
+	calendarFeedPast  = 180
+	calendarFeedAhead = 365
+	calendarUIDDomain = "workout-tracker-go.ilijakrilovic.com"
+)
+
+func (app *application) createCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Tokens.DeleteAllForMember(data.ScopeCalendar, memberID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	token, err := app.models.Tokens.New(memberID, calendarTokenTTL, data.ScopeCalendar)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	responseEnvelope := envelope{
+		"calendar_token": envelope{
+			"token":  token.Plaintext,
+			"expiry": token.Expiry,
+			"url":    fmt.Sprintf("/v1/members/%d/calendar.ics?token=%s", memberID, token.Plaintext),
+		},
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, responseEnvelope, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Tokens.DeleteAllForMember(data.ScopeCalendar, memberID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "calendar feed token revoked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getCalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	tokenPlaintext := r.URL.Query().Get("token")
+	if tokenPlaintext == "" {
+		app.invalidFeedTokenResponse(w, r)
+		return
+	}
+
+	member, err := app.models.Members.GetForToken(data.ScopeCalendar, tokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.invalidFeedTokenResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if member.ID != memberID {
+		app.invalidFeedTokenResponse(w, r)
+		return
+	}
+
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -calendarFeedPast)
+	to := today.AddDate(0, 0, calendarFeedAhead)
+
+	workouts, err := app.models.Workouts.GetInRange(memberID, from, to)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	schedule, err := app.models.Schedule.GetInRange(memberID, from, to)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	routines := make(map[int64]*data.Routine)
+
+	for _, scheduled := range schedule {
+		if scheduled.RoutineID == nil || routines[*scheduled.RoutineID] != nil {
+			continue
+		}
+
+		routine, err := app.models.Routines.Get(*scheduled.RoutineID, memberID)
+		if err != nil {
+			if errors.Is(err, data.ErrRecordNotFound) {
+				continue
+			}
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		routines[routine.ID] = routine
+	}
+
+	weightUnit := data.WeightUnit(member.PreferredUnits)
+
+	calendar := &ical.Calendar{
+		ProdID: "-//workout-tracker-go//Workout Tracker API " + version + "//EN",
+		Name:   member.Name + " workouts",
+	}
+
+	for _, scheduled := range schedule {
+		if scheduled.WorkoutID != nil {
+			continue
+		}
+
+		calendar.Events = append(calendar.Events, scheduledEvent(scheduled, routines, weightUnit, now))
+	}
+
+	for _, workout := range workouts {
+		workout.ConvertWeights(weightUnit)
+		calendar.Events = append(calendar.Events, workoutEvent(workout, now))
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="workouts.ics"`)
+
+	err = ical.Encode(w, calendar)
+	if err != nil {
+		app.logError(r, err)
+	}
+}
+
+func scheduledEvent(scheduled *data.ScheduledWorkout, routines map[int64]*data.Routine, weightUnit string, stamp time.Time) ical.Event {
+	event := ical.Event{
+		UID:     "scheduled-" + strconv.FormatInt(scheduled.ID, 10) + "@" + calendarUIDDomain,
+		Stamp:   stamp,
+		Start:   scheduled.Date,
+		End:     scheduled.Date.AddDate(0, 0, 1),
+		AllDay:  true,
+		Summary: scheduled.Title,
+		Status:  ical.StatusTentative,
+	}
+
+	switch scheduled.Status {
+	case data.ScheduleStatusCompleted:
+		event.Status = ical.StatusConfirmed
+	case data.ScheduleStatusSkipped:
+		event.Status = ical.StatusCancelled
+	}
+
+	lines := []string{}
+
+	if scheduled.RoutineID != nil && routines[*scheduled.RoutineID] != nil {
+		for _, exercise := range routines[*scheduled.RoutineID].Exercises {
+			target := fmt.Sprintf("%d x %d", exercise.TargetSets, exercise.TargetRepetitions)
+
+			switch {
+			case exercise.TargetDurationSeconds != nil:
+				target = fmt.Sprintf("%d x %ds", exercise.TargetSets, *exercise.TargetDurationSeconds)
+			case exercise.TargetDistanceMeters != nil:
+				target = fmt.Sprintf("%d x %gm", exercise.TargetSets, *exercise.TargetDistanceMeters)
+			case exercise.TargetWeight > 0:
+				target += fmt.Sprintf(" @ %g %s", data.FromKilograms(exercise.TargetWeight, weightUnit), weightUnit)
+			}
+
+			lines = append(lines, exercise.ExerciseName+": "+target)
+		}
+	}
+
+	if scheduled.Notes != "" {
+		lines = append(lines, scheduled.Notes)
+	}
+
+	event.Description = strings.Join(lines, "\n")
+
+	return event
+}
+
+func workoutEvent(workout *data.WorkoutResponse, stamp time.Time) ical.Event {
+	event := ical.Event{
+		UID:    "workout-" + strconv.FormatInt(workout.ID, 10) + "@" + calendarUIDDomain,
+		Stamp:  stamp,
+		Start:  workout.Date,
+		End:    workout.Date.Add(time.Hour),
+		Status: ical.StatusConfirmed,
+	}
+
+	if workout.Date.Equal(workout.Date.Truncate(24 * time.Hour)) {
+		event.AllDay = true
+		event.End = workout.Date.AddDate(0, 0, 1)
+	}
+
+	names := []string{}
+	lines := []string{}
+
+	for i, detail := range workout.Details {
+		set := formatSet(detail, workout.WeightUnit)
+
+		if i > 0 && workout.Details[i-1].Exercise.ID == detail.Exercise.ID {
+			lines[len(lines)-1] += ", " + set
+			continue
+		}
+
+		if !containsString(names, detail.Exercise.Name) {
+			names = append(names, detail.Exercise.Name)
+		}
+
+		lines = append(lines, detail.Exercise.Name+": "+set)
+	}
+
+	event.Summary = strings.Join(names, ", ")
+	if event.Summary == "" {
+		event.Summary = "Workout"
+	}
+
+	if workout.Notes != "" {
+		lines = append(lines, workout.Notes)
+	}
+
+	event.Description = strings.Join(lines, "\n")
+
+	return event
+}
+
+func formatSet(detail *data.WorkoutDetailResponse, weightUnit string) string {
+	switch {
+	case detail.DurationSeconds != nil:
+		return fmt.Sprintf("%ds", *detail.DurationSeconds)
+	case detail.DistanceMeters != nil:
+		return fmt.Sprintf("%gm", *detail.DistanceMeters)
+	case detail.Weight > 0:
+		return fmt.Sprintf("%g %s x %d", detail.Weight, weightUnit, detail.Repetitions)
+	default:
+		return fmt.Sprintf("%d reps", detail.Repetitions)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -9,7 +9,10 @@ import (
 
 type contextKey string
 
-const memberContextKey = contextKey("member")
+const (
+	memberContextKey  = contextKey("member")
+	sessionContextKey = contextKey("session")
+)
 
 func (app *application) contextSetMember(r *http.Request, member *data.Member) *http.Request {
 	ctx := context.WithValue(r.Context(), memberContextKey, member)
@@ -24,3 +27,23 @@ func (app *application) contextGetMember(r *http.Request) *data.Member {
 
 	return member
 }
+
+func (app *application) contextSetSessionID(r *http.Request, sessionID int64) *http.Request {
+	ctx := context.WithValue(r.Context(), sessionContextKey, sessionID)
+	return r.WithContext(ctx)
+}
+
+func (app *application) contextGetSessionID(r *http.Request) int64 {
+	sessionID, _ := r.Context().Value(sessionContextKey).(int64)
+	return sessionID
+}
+
+func (app *application) callerUnits(r *http.Request) string {
+	member := app.contextGetMember(r)
+
+	if member.IsAnonymous() || member.PreferredUnits == "" {
+		return data.UnitsMetric
+	}
+
+	return member.PreferredUnits
+}
@@ -9,24 +9,51 @@ import (
 	"workout-tracker-go.ilijakrilovic.com/internal/validator"
 )
 
-func (app *application) createExerciseHandler(w http.ResponseWriter, r *http.Request) {
+type exerciseInput struct {
+	Name             string   `json:"name"`
+	Category         string   `json:"category"`
+	Description      string   `json:"description"`
+	Equipment        string   `json:"equipment"`
+	PrimaryMuscles   []string `json:"primary_muscles"`
+	SecondaryMuscles []string `json:"secondary_muscles"`
+	Mechanic         string   `json:"mechanic"`
+	MeasurementType  string   `json:"measurement_type"`
+}
+
+func (input exerciseInput) copyTo(exercise *data.Exercise) {
+	exercise.Name = input.Name
+	exercise.Category = input.Category
+	exercise.Description = input.Description
+	exercise.Equipment = input.Equipment
+	exercise.PrimaryMuscles = input.PrimaryMuscles
+	exercise.SecondaryMuscles = input.SecondaryMuscles
+	exercise.Mechanic = input.Mechanic
+	exercise.MeasurementType = input.MeasurementType
 
-	var input struct {
-		Name        string `json:"name"`
-		Category    string `json:"category"`
-		Description string `json:"description"`
+	if exercise.MeasurementType == "" {
+		exercise.MeasurementType = data.MeasurementWeightReps
 	}
+}
+
+func normalizeValues(values []string) []string {
+	for i := range values {
+		values[i] = strings.ToLower(strings.TrimSpace(values[i]))
+	}
+	return values
+}
+
+func (app *application) createExerciseHandler(w http.ResponseWriter, r *http.Request) {
+
+	var input exerciseInput
 
 	err := app.readJSON(w, r, &input)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
+		return
 	}
 
-	exercise := &data.Exercise{
-		Name:        input.Name,
-		Category:    input.Category,
-		Description: input.Description,
-	}
+	exercise := &data.Exercise{}
+	input.copyTo(exercise)
 
 	v := validator.New()
 
@@ -47,24 +74,40 @@ func (app *application) createExerciseHandler(w http.ResponseWriter, r *http.Req
 	}
 }
 
-func (app *application) getExercisesByCategoryHandler(w http.ResponseWriter, r *http.Request) {
+func (app *application) listExercisesHandler(w http.ResponseWriter, r *http.Request) {
 
-	category := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("category")))
+	qs := r.URL.Query()
+
+	var exerciseFilters data.ExerciseFilters
+
+	exerciseFilters.Search = strings.TrimSpace(app.readString(qs, "q", ""))
+	exerciseFilters.Categories = normalizeValues(app.readCSV(qs, "category", []string{}))
+	exerciseFilters.Equipment = normalizeValues(app.readCSV(qs, "equipment", []string{}))
+	exerciseFilters.Muscles = normalizeValues(app.readCSV(qs, "muscle", []string{}))
+	exerciseFilters.Mechanic = strings.ToLower(app.readString(qs, "mechanic", ""))
+	exerciseFilters.MeasurementTypes = normalizeValues(app.readCSV(qs, "measurement_type", []string{}))
 
 	v := validator.New()
 
-	if data.ValidateCategory(v, category); !v.Valid() {
+	filters := app.readFilters(qs, "id", v)
+	filters.SortSafelist = []string{"id", "name", "category", "equipment", "-id", "-name", "-category", "-equipment"}
+
+	data.ValidateExerciseFilters(v, exerciseFilters)
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
 		app.failedValidationResponse(w, r, v.Errors)
 		return
 	}
 
-	exercises, err := app.models.Exercises.GetByCategory(category)
+	member := app.contextGetMember(r)
+
+	exercises, metadata, err := app.models.Exercises.GetAll(exerciseFilters, member.ID, filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"exercises": exercises}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"exercises": exercises, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -74,6 +117,7 @@ func (app *application) updateExerciseHandler(w http.ResponseWriter, r *http.Req
 	id, err := app.readIDParam(r)
 	if err != nil {
 		app.notFoundResponse(w, r)
+		return
 	}
 
 	exercise, err := app.models.Exercises.GetById(id)
@@ -87,25 +131,36 @@ func (app *application) updateExerciseHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	var input struct {
-		Name        string `json:"name"`
-		Category    string `json:"category"`
-		Description string `json:"description"`
+	if exercise.IsPrivate() {
+		app.notFoundResponse(w, r)
+		return
 	}
 
+	var input exerciseInput
+
 	err = app.readJSON(w, r, &input)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
 
-	exercise.Name = input.Name
-	exercise.Category = input.Category
-	exercise.Description = input.Description
+	input.copyTo(exercise)
+
+	v := validator.New()
+
+	if data.ValidateExercise(v, exercise); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
 
 	err = app.models.Exercises.Update(exercise)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -120,6 +175,7 @@ func (app *application) deleteExerciseHandler(w http.ResponseWriter, r *http.Req
 	id, err := app.readIDParam(r)
 	if err != nil || id < 1 {
 		app.notFoundResponse(w, r)
+		return
 	}
 
 	err = app.models.Exercises.Delete(id)
@@ -138,3 +194,136 @@ func (app *application) deleteExerciseHandler(w http.ResponseWriter, r *http.Req
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+func (app *application) createMemberExerciseHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input exerciseInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	exercise := &data.Exercise{OwnerMemberID: &memberID}
+	input.copyTo(exercise)
+
+	v := validator.New()
+
+	if data.ValidateExercise(v, exercise); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Exercises.Insert(exercise)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"exercise": exercise}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateMemberExerciseHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readNamedIDParam(r, "exercise_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	exercise, err := app.models.Exercises.GetById(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !exercise.IsOwnedBy(memberID) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input exerciseInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	input.copyTo(exercise)
+
+	v := validator.New()
+
+	if data.ValidateExercise(v, exercise); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Exercises.Update(exercise)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"exercise": exercise}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteMemberExerciseHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readNamedIDParam(r, "exercise_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Exercises.DeletePrivate(id, memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"exercise": "exercise sucessfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
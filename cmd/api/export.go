@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatJSONL  = "jsonl"
+	exportFormatStrong = "strong"
+
+	exportFlushEvery = 500
+	metersPerMile    = 1609.344
+)
+
+var exportCSVHeader = []string{
+	"date", "workout_id", "workout_notes", "exercise_id", "exercise_name", "category", "position", "set", "set_type",
+	"repetitions", "weight", "weight_unit", "duration_seconds", "distance_meters", "heart_rate", "calories",
+	"rpe", "rir", "tempo", "rest_seconds", "notes", "planned",
+}
+
+var strongCSVHeader = []string{
+	"Date", "Workout Name", "Duration", "Exercise Name", "Set Order", "Weight", "Reps",
+	"Distance", "Seconds", "Notes", "Workout Notes", "RPE",
+}
+
+func (app *application) exportWorkoutsHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	var workoutFilters data.WorkoutFilters
+
+	workoutFilters.From = app.readDate(qs, "from", v)
+	workoutFilters.To = app.readDate(qs, "to", v)
+	workoutFilters.ExerciseID = int64(app.readInt(qs, "exercise_id", 0, v))
+	workoutFilters.Category = strings.ToLower(app.readString(qs, "category", ""))
+	format := strings.ToLower(app.readString(qs, "format", exportFormatCSV))
+
+	units := app.callerUnits(r)
+	weightUnit := data.WeightUnit(units)
+
+	workoutFilters.MinWeight = app.readWeight(qs, "min_weight", weightUnit, v)
+
+	v.Check(validator.PermittedValue(format, exportFormatCSV, exportFormatJSONL, exportFormatStrong), "format", "must be one of csv, jsonl or strong")
+
+	if data.ValidateWorkoutFilters(v, workoutFilters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	filename := fmt.Sprintf("workouts-%d.%s", memberID, format)
+	contentType := "text/csv; charset=utf-8"
+
+	switch format {
+	case exportFormatJSONL:
+		contentType = "application/x-ndjson"
+	case exportFormatStrong:
+		filename = fmt.Sprintf("workouts-%d-strong.csv", memberID)
+	}
+
+	ew := &exportWriter{
+		w:           w,
+		contentType: contentType,
+		filename:    filename,
+	}
+
+	var (
+		encode func(*data.WorkoutExportRow) error
+		flush  func()
+	)
+
+	flusher, _ := w.(http.Flusher)
+
+	switch format {
+	case exportFormatJSONL:
+		encoder := json.NewEncoder(ew)
+
+		encode = func(row *data.WorkoutExportRow) error {
+			row.Weight = data.FromKilograms(row.Weight, weightUnit)
+			row.WeightUnit = weightUnit
+			return encoder.Encode(row)
+		}
+		flush = func() {}
+	default:
+		writer := csv.NewWriter(ew)
+
+		record := func(row *data.WorkoutExportRow) []string {
+			return exportRecord(row, weightUnit)
+		}
+
+		if format == exportFormatStrong {
+			writer.Write(strongCSVHeader)
+			record = func(row *data.WorkoutExportRow) []string {
+				return strongRecord(row, units)
+			}
+		} else {
+			writer.Write(exportCSVHeader)
+		}
+
+		encode = func(row *data.WorkoutExportRow) error {
+			if row.Planned && format == exportFormatStrong {
+				return nil
+			}
+			return writer.Write(record(row))
+		}
+		flush = writer.Flush
+	}
+
+	written := 0
+
+	write := func(row *data.WorkoutExportRow) error {
+		err := encode(row)
+		if err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushEvery == 0 {
+			flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		return nil
+	}
+
+	err = app.models.Workouts.Export(r.Context(), memberID, workoutFilters, write)
+	if err != nil && !ew.started {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	ew.start()
+	flush()
+
+	if err != nil {
+		app.logError(r, err)
+	}
+}
+
+type exportWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (ew *exportWriter) start() {
+	if ew.started {
+		return
+	}
+
+	ew.started = true
+	ew.w.Header().Set("Content-Type", ew.contentType)
+	ew.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, ew.filename))
+}
+
+func (ew *exportWriter) Write(p []byte) (int, error) {
+	ew.start()
+	return ew.w.Write(p)
+}
+
+func exportRecord(row *data.WorkoutExportRow, weightUnit string) []string {
+	return []string{
+		row.Date.Format(time.RFC3339),
+		strconv.FormatInt(row.WorkoutID, 10),
+		row.WorkoutNotes,
+		strconv.FormatInt(row.ExerciseID, 10),
+		row.ExerciseName,
+		row.Category,
+		strconv.Itoa(row.Position),
+		strconv.Itoa(row.Set),
+		row.SetType,
+		strconv.Itoa(row.Repetitions),
+		formatFloat(data.FromKilograms(row.Weight, weightUnit)),
+		weightUnit,
+		formatOptionalInt(row.DurationSeconds),
+		formatOptionalFloat(row.DistanceMeters),
+		formatOptionalInt(row.HeartRate),
+		formatOptionalInt(row.Calories),
+		formatOptionalFloat(row.RPE),
+		formatOptionalInt(row.RIR),
+		row.Tempo,
+		formatOptionalInt(row.RestSeconds),
+		row.Notes,
+		strconv.FormatBool(row.Planned),
+	}
+}
+
+func strongRecord(row *data.WorkoutExportRow, units string) []string {
+	distance := ""
+	if row.DistanceMeters != nil {
+		if units == data.UnitsImperial {
+			distance = formatFloat(*row.DistanceMeters / metersPerMile)
+		} else {
+			distance = formatFloat(*row.DistanceMeters / 1000)
+		}
+	}
+
+	seconds := "0"
+	if row.DurationSeconds != nil {
+		seconds = strconv.Itoa(*row.DurationSeconds)
+	}
+
+	return []string{
+		row.Date.Format("2006-01-02 15:04:05"),
+		"Workout",
+		"",
+		row.ExerciseName,
+		strconv.Itoa(row.Set),
+		formatFloat(data.FromKilograms(row.Weight, data.WeightUnit(units))),
+		strconv.Itoa(row.Repetitions),
+		distance,
+		seconds,
+		row.Notes,
+		row.WorkoutNotes,
+		formatOptionalFloat(row.RPE),
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return formatFloat(*value)
+}
+
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
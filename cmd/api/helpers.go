@@ -2,11 +2,17 @@ package main
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
 )
 
 type envelope map[string]interface{}
@@ -49,13 +55,149 @@ func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst int
 }
 
 func (app *application) readIDParam(r *http.Request) (int64, error) {
+	return app.readNamedIDParam(r, "id")
+}
+
+func (app *application) readNamedIDParam(r *http.Request, name string) (int64, error) {
 
 	params := httprouter.ParamsFromContext(r.Context())
 
-	id, err := strconv.ParseInt(params.ByName("id"), 10, 64)
-	if err != nil {
-		return 0, errors.New("invalid id parameter")
+	id, err := strconv.ParseInt(params.ByName(name), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid %s parameter", name)
 	}
 
 	return id, nil
 }
+
+func (app *application) readString(qs url.Values, key string, defaultValue string) string {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	return s
+}
+
+func (app *application) readCSV(qs url.Values, key string, defaultValue []string) []string {
+	csv := qs.Get(key)
+
+	if csv == "" {
+		return defaultValue
+	}
+
+	return strings.Split(csv, ",")
+}
+
+func (app *application) readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		v.AddError(key, "must be an integer value")
+		return defaultValue
+	}
+
+	return i
+}
+
+func (app *application) readFloat(qs url.Values, key string, defaultValue float64, v *validator.Validator) float64 {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		v.AddError(key, "must be a number")
+		return defaultValue
+	}
+
+	return f
+}
+
+func (app *application) readWeight(qs url.Values, key string, unit string, v *validator.Validator) float64 {
+	weight := data.ToKilograms(app.readFloat(qs, key, 0, v), unit)
+
+	if unit == data.UnitPounds {
+		weight = math.Floor(weight*100) / 100
+	}
+
+	return weight
+}
+
+func (app *application) readBool(qs url.Values, key string, defaultValue bool, v *validator.Validator) bool {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean value")
+		return defaultValue
+	}
+
+	return b
+}
+
+func (app *application) readDate(qs url.Values, key string, v *validator.Validator) *time.Time {
+	s := qs.Get(key)
+
+	if s == "" {
+		return nil
+	}
+
+	t, ok := app.parseDate(key, s, v)
+	if !ok {
+		return nil
+	}
+
+	return &t
+}
+
+func (app *application) parseDate(key, s string, v *validator.Validator) (time.Time, bool) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		v.AddError(key, "must be a date in the format YYYY-MM-DD")
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+func (app *application) readFilters(qs url.Values, defaultSort string, v *validator.Validator) data.Filters {
+	return data.Filters{
+		Page:     app.readInt(qs, "page", 1, v),
+		PageSize: app.readInt(qs, "page_size", 20, v),
+		Sort:     app.readString(qs, "sort", defaultSort),
+	}
+}
+
+func (app *application) background(fn func()) {
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				app.logger.Printf("background task panicked: %v", err)
+			}
+		}()
+
+		fn()
+	}()
+}
+
+func (app *application) sendEmail(recipient, templateFile string, templateData map[string]interface{}) {
+	app.background(func() {
+		err := app.mailer.Send(recipient, templateFile, templateData)
+		if err != nil {
+			app.logger.Printf("sending %s to %s: %v", templateFile, recipient, err)
+		}
+	})
+}
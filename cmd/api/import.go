@@ -0,0 +1,704 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+const (
+	importFormatCSV    = "csv"
+	importFormatStrong = "strong"
+	importFormatHevy   = "hevy"
+
+	maxImportBytes = 10 << 20
+	maxImportRows  = 50_000
+)
+
+type importRow struct {
+	line         int
+	workoutKey   string
+	date         time.Time
+	workoutNotes string
+	exerciseName string
+	detail       *data.WorkoutDetail
+}
+
+type importMatch struct {
+	name     string
+	exercise *data.Exercise
+	score    float64
+	created  bool
+	sets     int
+}
+
+type exerciseUsage struct {
+	weight   bool
+	reps     bool
+	distance bool
+	duration bool
+}
+
+func (u *exerciseUsage) add(detail *data.WorkoutDetail) {
+	u.weight = u.weight || detail.Weight > 0
+	u.reps = u.reps || detail.Repetitions > 0
+	u.distance = u.distance || detail.DistanceMeters != nil
+	u.duration = u.duration || detail.DurationSeconds != nil
+}
+
+func (u *exerciseUsage) measurementType() string {
+	switch {
+	case u.weight:
+		return data.MeasurementWeightReps
+	case u.reps:
+		return data.MeasurementBodyweightReps
+	case u.distance:
+		return data.MeasurementDistance
+	case u.duration:
+		return data.MeasurementTime
+	default:
+		return data.MeasurementWeightReps
+	}
+}
+
+type importReport struct {
+	Format             string                   `json:"format"`
+	DryRun             bool                     `json:"dry_run"`
+	Rows               int                      `json:"rows"`
+	Workouts           []*importWorkoutSummary  `json:"workouts"`
+	Exercises          []*importExerciseSummary `json:"exercises"`
+	UnmatchedExercises []string                 `json:"unmatched_exercises"`
+	Errors             map[string]string        `json:"errors,omitempty"`
+}
+
+type importWorkoutSummary struct {
+	ID        int64     `json:"id,omitempty"`
+	Date      time.Time `json:"date"`
+	Notes     string    `json:"notes"`
+	Sets      int       `json:"sets"`
+	Exercises []string  `json:"exercises"`
+}
+
+type importExerciseSummary struct {
+	Name        string  `json:"name"`
+	ExerciseID  int64   `json:"exercise_id,omitempty"`
+	MatchedName string  `json:"matched_name"`
+	Score       float64 `json:"score"`
+	Created     bool    `json:"created"`
+	Sets        int     `json:"sets"`
+}
+
+func (app *application) importWorkoutsHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	dryRun := app.readBool(qs, "dry_run", false, v)
+	createExercises := app.readBool(qs, "create_exercises", false, v)
+	category := strings.ToLower(app.readString(qs, "category", ""))
+	unit := app.readString(qs, "unit", data.WeightUnit(app.callerUnits(r)))
+
+	data.ValidateWeightUnit(v, unit)
+
+	if category != "" {
+		data.ValidateCategories(v, []string{category})
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	err = r.ParseMultipartForm(maxImportBytes)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		switch {
+		case errors.Is(err, http.ErrMissingFile):
+			app.failedValidationResponse(w, r, map[string]string{"file": "must be provided"})
+		default:
+			app.badRequestResponse(w, r, err)
+		}
+		return
+	}
+	defer file.Close()
+
+	format, rows, err := readImportRows(file, unit, v)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if v.Errors["file"] != "" {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	exercises, err := app.models.Exercises.GetVisible(memberID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	usage := make(map[string]*exerciseUsage)
+	names := []string{}
+
+	for _, row := range rows {
+		if usage[row.exerciseName] == nil {
+			usage[row.exerciseName] = &exerciseUsage{}
+			names = append(names, row.exerciseName)
+		}
+		usage[row.exerciseName].add(row.detail)
+	}
+
+	matches := make(map[string]*importMatch)
+	unmatched := []string{}
+
+	for _, name := range names {
+		exercise, score := data.MatchExercise(name, exercises)
+
+		match := &importMatch{name: name, exercise: exercise, score: score}
+
+		if exercise == nil {
+			if !createExercises {
+				unmatched = append(unmatched, name)
+				continue
+			}
+
+			match.exercise = newImportedExercise(v, name, usage[name], category, memberID)
+			match.score = 0
+			match.created = true
+		}
+
+		matches[name] = match
+	}
+
+	if len(unmatched) > 0 {
+		v.AddError("exercises", "no matching exercise for "+strings.Join(unmatched, ", ")+"; retry with create_exercises=true to create them")
+	}
+
+	workouts := []*data.ImportedWorkout{}
+	byKey := make(map[string]*data.ImportedWorkout)
+	setNumbers := make(map[string]int)
+
+	for _, row := range rows {
+		match := matches[row.exerciseName]
+		if match == nil {
+			continue
+		}
+
+		imported, ok := byKey[row.workoutKey]
+		if !ok {
+			imported = &data.ImportedWorkout{
+				Workout: &data.Workout{
+					MemberID: memberID,
+					Date:     row.date,
+					Notes:    row.workoutNotes,
+				},
+			}
+
+			byKey[row.workoutKey] = imported
+			workouts = append(workouts, imported)
+		}
+
+		setKey := row.workoutKey + "\x00" + row.exerciseName
+		setNumbers[setKey]++
+
+		row.detail.Set = setNumbers[setKey]
+		row.detail.ExerciseID = match.exercise.ID
+
+		data.ValidateWorkoutDetail(v, fmt.Sprintf("lines[%d].", row.line), row.detail, match.exercise.MeasurementType)
+
+		imported.Workout.Details = append(imported.Workout.Details, row.detail)
+		imported.Exercises = append(imported.Exercises, match.exercise)
+		match.sets++
+	}
+
+	sort.SliceStable(workouts, func(i, j int) bool {
+		return workouts[i].Workout.Date.Before(workouts[j].Workout.Date)
+	})
+
+	if dryRun {
+		report := newImportReport(format, true, len(rows), workouts, names, matches, unmatched)
+		if !v.Valid() {
+			report.Errors = v.Errors
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"import": report}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Workouts.Import(workouts)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidExercise):
+			app.failedValidationResponse(w, r, map[string]string{"exercises": "must only reference existing exercises available to the member"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	report := newImportReport(format, false, len(rows), workouts, names, matches, unmatched)
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"import": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func newImportedExercise(v *validator.Validator, name string, usage *exerciseUsage, category string, memberID int64) *data.Exercise {
+	exercise := &data.Exercise{
+		Name:            name,
+		Category:        category,
+		MeasurementType: usage.measurementType(),
+		OwnerMemberID:   &memberID,
+	}
+
+	if exercise.Category == "" && (exercise.MeasurementType == data.MeasurementDistance || exercise.MeasurementType == data.MeasurementTime) {
+		exercise.Category = "cardio"
+	}
+
+	ev := validator.New()
+
+	if data.ValidateExercise(ev, exercise); !ev.Valid() {
+		for key, message := range ev.Errors {
+			v.AddError(fmt.Sprintf("exercises[%s].%s", name, key), message)
+		}
+	}
+
+	return exercise
+}
+
+func newImportReport(format string, dryRun bool, rows int, workouts []*data.ImportedWorkout, names []string, matches map[string]*importMatch, unmatched []string) *importReport {
+	report := &importReport{
+		Format:             format,
+		DryRun:             dryRun,
+		Rows:               rows,
+		Workouts:           []*importWorkoutSummary{},
+		Exercises:          []*importExerciseSummary{},
+		UnmatchedExercises: unmatched,
+	}
+
+	for _, imported := range workouts {
+		summary := &importWorkoutSummary{
+			ID:        imported.Workout.ID,
+			Date:      imported.Workout.Date,
+			Notes:     imported.Workout.Notes,
+			Sets:      len(imported.Workout.Details),
+			Exercises: []string{},
+		}
+
+		seen := make(map[*data.Exercise]bool)
+		for _, exercise := range imported.Exercises {
+			if !seen[exercise] {
+				seen[exercise] = true
+				summary.Exercises = append(summary.Exercises, exercise.Name)
+			}
+		}
+
+		report.Workouts = append(report.Workouts, summary)
+	}
+
+	for _, name := range names {
+		match := matches[name]
+		if match == nil {
+			continue
+		}
+
+		report.Exercises = append(report.Exercises, &importExerciseSummary{
+			Name:        match.name,
+			ExerciseID:  match.exercise.ID,
+			MatchedName: match.exercise.Name,
+			Score:       match.score,
+			Created:     match.created,
+			Sets:        match.sets,
+		})
+	}
+
+	return report
+}
+
+func readImportRows(file multipart.File, weightUnit string, v *validator.Validator) (string, []*importRow, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == nil && len(header) == 1 && strings.Contains(header[0], ";") {
+		_, err = file.Seek(0, io.SeekStart)
+		if err != nil {
+			return "", nil, err
+		}
+
+		reader = csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.Comma = ';'
+
+		header, err = reader.Read()
+	}
+
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			v.AddError("file", "must not be empty")
+			return "", nil, nil
+		}
+		return "", nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, column := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))] = i
+	}
+
+	var (
+		format string
+		parse  func(importRecord) *importRow
+	)
+
+	switch {
+	case hasColumns(columns, "Exercise Name", "Set Order"):
+		format = importFormatStrong
+		parse = func(record importRecord) *importRow {
+			return parseStrongRow(record, weightUnit)
+		}
+	case hasColumns(columns, "exercise_title", "start_time"):
+		format = importFormatHevy
+		parse = parseHevyRow
+	case hasColumns(columns, "exercise_name", "date"):
+		format = importFormatCSV
+		parse = func(record importRecord) *importRow {
+			return parseExportRow(record, weightUnit)
+		}
+	default:
+		v.AddError("file", "must be a Strong, Hevy or workout export CSV file")
+		return "", nil, nil
+	}
+
+	rows := []*importRow{}
+
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		record := importRecord{
+			columns: columns,
+			fields:  fields,
+			v:       v,
+			prefix:  fmt.Sprintf("lines[%d].", line),
+		}
+
+		row := parse(record)
+		if row == nil {
+			continue
+		}
+
+		row.line = line
+
+		if row.exerciseName == "" {
+			v.AddError(record.prefix+"exercise_name", "must be provided")
+			continue
+		}
+
+		v.Check(len(row.workoutNotes) <= 1000, record.prefix+"workout_notes", "must not be more than 1000 bytes long")
+
+		rows = append(rows, row)
+
+		if len(rows) > maxImportRows {
+			v.AddError("file", fmt.Sprintf("must not contain more than %d sets", maxImportRows))
+			return format, nil, nil
+		}
+	}
+
+	if len(rows) == 0 {
+		v.AddError("file", "must contain at least one set")
+	}
+
+	return format, rows, nil
+}
+
+func hasColumns(columns map[string]int, names ...string) bool {
+	for _, name := range names {
+		if _, ok := columns[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+type importRecord struct {
+	columns map[string]int
+	fields  []string
+	v       *validator.Validator
+	prefix  string
+}
+
+func (r importRecord) has(column string) bool {
+	_, ok := r.columns[column]
+	return ok
+}
+
+func (r importRecord) get(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[i])
+}
+
+func (r importRecord) float(column, key string) float64 {
+	s := r.get(column)
+	if s == "" {
+		return 0
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		r.v.AddError(r.prefix+key, "must be a number")
+		return 0
+	}
+
+	return f
+}
+
+func (r importRecord) int(column, key string) int {
+	return int(math.Round(r.float(column, key)))
+}
+
+func (r importRecord) optionalInt(column, key string) *int {
+	if r.get(column) == "" {
+		return nil
+	}
+
+	i := r.int(column, key)
+	return &i
+}
+
+func (r importRecord) nonZeroFloat(column, key string) *float64 {
+	f := r.float(column, key)
+	if f == 0 {
+		return nil
+	}
+	return &f
+}
+
+func (r importRecord) nonZeroInt(column, key string) *int {
+	i := r.int(column, key)
+	if i == 0 {
+		return nil
+	}
+	return &i
+}
+
+func (r importRecord) date(column string, layouts ...string) time.Time {
+	s := r.get(column)
+
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t
+		}
+	}
+
+	r.v.AddError(r.prefix+"date", "must be a valid date")
+	return time.Time{}
+}
+
+func (r importRecord) distance(column string, metersPerUnit float64) *float64 {
+	distance := r.nonZeroFloat(column, "distance")
+	if distance == nil {
+		return nil
+	}
+
+	meters := *distance * metersPerUnit
+	return &meters
+}
+
+func importWeightUnit(unit, defaultUnit string) string {
+	switch strings.ToLower(unit) {
+	case "kg", "kgs":
+		return data.UnitKilograms
+	case "lb", "lbs":
+		return data.UnitPounds
+	default:
+		return defaultUnit
+	}
+}
+
+func parseStrongRow(r importRecord, weightUnit string) *importRow {
+	setOrder := r.get("Set Order")
+	if strings.EqualFold(setOrder, "Rest Timer") {
+		return nil
+	}
+
+	setType := data.SetTypeWorking
+	switch strings.ToUpper(setOrder) {
+	case "W":
+		setType = data.SetTypeWarmUp
+	case "D":
+		setType = data.SetTypeDropSet
+	case "F":
+		setType = data.SetTypeFailure
+	}
+
+	if r.has("Weight Unit") {
+		weightUnit = importWeightUnit(r.get("Weight Unit"), weightUnit)
+	}
+
+	metersPerUnit := 1000.0
+	if weightUnit == data.UnitPounds {
+		metersPerUnit = metersPerMile
+	}
+
+	if r.has("Distance Unit") {
+		switch strings.ToLower(r.get("Distance Unit")) {
+		case "mi":
+			metersPerUnit = metersPerMile
+		case "km":
+			metersPerUnit = 1000
+		case "m":
+			metersPerUnit = 1
+		}
+	}
+
+	workoutName := r.get("Workout Name")
+
+	notes := r.get("Workout Notes")
+	if notes == "" {
+		notes = workoutName
+	}
+
+	return &importRow{
+		workoutKey:   r.get("Date") + "\x00" + workoutName,
+		date:         r.date("Date", "2006-01-02 15:04:05", "2006-01-02 15:04", time.RFC3339),
+		workoutNotes: notes,
+		exerciseName: r.get("Exercise Name"),
+		detail: &data.WorkoutDetail{
+			Repetitions:     r.int("Reps", "repetitions"),
+			Weight:          data.ToKilograms(r.float("Weight", "weight"), weightUnit),
+			DurationSeconds: r.nonZeroInt("Seconds", "duration_seconds"),
+			DistanceMeters:  r.distance("Distance", metersPerUnit),
+			RPE:             r.nonZeroFloat("RPE", "rpe"),
+			SetType:         setType,
+			Notes:           r.get("Notes"),
+		},
+	}
+}
+
+func parseHevyRow(r importRecord) *importRow {
+	weightUnit, weightColumn := data.UnitKilograms, "weight_kg"
+	if r.has("weight_lbs") {
+		weightUnit, weightColumn = data.UnitPounds, "weight_lbs"
+	}
+
+	distanceColumn, metersPerUnit := "distance_km", 1000.0
+	if r.has("distance_miles") {
+		distanceColumn, metersPerUnit = "distance_miles", metersPerMile
+	}
+
+	setType := data.SetTypeWorking
+	switch r.get("set_type") {
+	case "warmup":
+		setType = data.SetTypeWarmUp
+	case "dropset":
+		setType = data.SetTypeDropSet
+	case "failure":
+		setType = data.SetTypeFailure
+	}
+
+	title := r.get("title")
+
+	notes := r.get("description")
+	if notes == "" {
+		notes = title
+	}
+
+	return &importRow{
+		workoutKey:   r.get("start_time") + "\x00" + title,
+		date:         r.date("start_time", "2 Jan 2006, 15:04", "2006-01-02 15:04:05", time.RFC3339),
+		workoutNotes: notes,
+		exerciseName: r.get("exercise_title"),
+		detail: &data.WorkoutDetail{
+			Repetitions:     r.int("reps", "repetitions"),
+			Weight:          data.ToKilograms(r.float(weightColumn, "weight"), weightUnit),
+			DurationSeconds: r.nonZeroInt("duration_seconds", "duration_seconds"),
+			DistanceMeters:  r.distance(distanceColumn, metersPerUnit),
+			RPE:             r.nonZeroFloat("rpe", "rpe"),
+			SetType:         setType,
+			Notes:           r.get("exercise_notes"),
+		},
+	}
+}
+
+func parseExportRow(r importRecord, weightUnit string) *importRow {
+	weightUnit = importWeightUnit(r.get("weight_unit"), weightUnit)
+
+	workoutKey := r.get("workout_id")
+	if workoutKey == "" {
+		workoutKey = r.get("date")
+	}
+
+	setType := strings.ToLower(r.get("set_type"))
+	if setType == "" {
+		setType = data.SetTypeWorking
+	}
+
+	return &importRow{
+		workoutKey:   workoutKey,
+		date:         r.date("date", time.RFC3339, "2006-01-02"),
+		workoutNotes: r.get("workout_notes"),
+		exerciseName: r.get("exercise_name"),
+		detail: &data.WorkoutDetail{
+			Repetitions:     r.int("repetitions", "repetitions"),
+			Weight:          data.ToKilograms(r.float("weight", "weight"), weightUnit),
+			DurationSeconds: r.nonZeroInt("duration_seconds", "duration_seconds"),
+			DistanceMeters:  r.nonZeroFloat("distance_meters", "distance_meters"),
+			HeartRate:       r.nonZeroInt("heart_rate", "heart_rate"),
+			Calories:        r.optionalInt("calories", "calories"),
+			RPE:             r.nonZeroFloat("rpe", "rpe"),
+			RIR:             r.optionalInt("rir", "rir"),
+			Tempo:           r.get("tempo"),
+			RestSeconds:     r.optionalInt("rest_seconds", "rest_seconds"),
+			SetType:         setType,
+			Notes:           r.get("notes"),
+			Planned:         r.get("planned") == "true",
+		},
+	}
+}
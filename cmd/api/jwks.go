@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/keyring"
+)
+
+func (app *application) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	keys := app.keyring.JWKS()
+	if keys == nil {
+		keys = []keyring.JWK{}
+	}
+
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "public, max-age=300")
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"keys": keys}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
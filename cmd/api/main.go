@@ -7,10 +7,13 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/keyring"
+	"workout-tracker-go.ilijakrilovic.com/internal/mailer"
 )
 
 const version = "1.0.0"
@@ -22,14 +25,28 @@ type config struct {
 		dsn string
 	}
 	jwt struct {
-		secret string
+		secret           string
+		signingKey       string
+		verificationKeys []string
 	}
+	smtp struct {
+		host     string
+		port     int
+		username string
+		password string
+		sender   string
+	}
+	mailDir         string
+	adminEmail      string
+	devExposeTokens bool
 }
 
 type application struct {
-	config config
-	logger *log.Logger
-	models data.Models
+	config  config
+	logger  *log.Logger
+	models  data.Models
+	mailer  mailer.Mailer
+	keyring *keyring.Keyring
 }
 
 func main() {
@@ -43,11 +60,44 @@ func main() {
 	flag.IntVar(&cfg.port, "port", 4000, "API server port")
 	flag.StringVar(&cfg.env, "env", "development", "Environment (developement|staging|production)")
 	flag.StringVar(&cfg.db.dsn, "db-dsn", os.Getenv("DATABASE_URL"), "NEON PostgreSQL DSN")
-	flag.StringVar(&cfg.jwt.secret, "jwt-secret", os.Getenv("JWT_SECRET"), "JWT secret")
+	flag.StringVar(&cfg.jwt.secret, "jwt-secret", os.Getenv("JWT_SECRET"), "JWT secret (HS256, used when no signing key is set)")
+	flag.StringVar(&cfg.jwt.signingKey, "jwt-signing-key", os.Getenv("JWT_SIGNING_KEY"), "PEM file with the RSA or Ed25519 private key used to sign JWTs")
+
+	flag.Func("jwt-verification-keys", "Comma-separated PEM files with extra public keys accepted for JWTs (e.g. retired signing keys)", func(val string) error {
+		cfg.jwt.verificationKeys = splitFiles(val)
+		return nil
+	})
+	cfg.jwt.verificationKeys = splitFiles(os.Getenv("JWT_VERIFICATION_KEYS"))
+
+	flag.StringVar(&cfg.smtp.host, "smtp-host", os.Getenv("SMTP_HOST"), "SMTP host (emails are logged when empty)")
+	flag.IntVar(&cfg.smtp.port, "smtp-port", 587, "SMTP port")
+	flag.StringVar(&cfg.smtp.username, "smtp-username", os.Getenv("SMTP_USERNAME"), "SMTP username")
+	flag.StringVar(&cfg.smtp.password, "smtp-password", os.Getenv("SMTP_PASSWORD"), "SMTP password")
+	flag.StringVar(&cfg.smtp.sender, "smtp-sender", "Workout Tracker <no-reply@workout-tracker-go.ilijakrilovic.com>", "SMTP sender")
+	flag.StringVar(&cfg.mailDir, "mail-dir", "", "Directory to write emails to instead of logging them when no SMTP host is set")
+	flag.BoolVar(&cfg.devExposeTokens, "dev-expose-tokens", false, "Return activation tokens in responses and show tokens in logged emails (development only)")
+	flag.StringVar(&cfg.adminEmail, "admin-email", os.Getenv("ADMIN_EMAIL"), "Grant members:admin to the existing member with this email on startup")
 	flag.Parse()
 
 	logger := log.New(os.Stdout, "", log.Ldate|log.Ltime)
 
+	if cfg.env == "production" && cfg.smtp.host == "" {
+		logger.Fatal("an SMTP host is required in production")
+	}
+
+	if cfg.devExposeTokens && cfg.env != "development" {
+		logger.Fatal("-dev-expose-tokens can only be used with -env=development")
+	}
+
+	keys, err := keyring.New(cfg.jwt.signingKey, cfg.jwt.verificationKeys, cfg.jwt.secret)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if id := keys.SigningKeyID(); id != "" {
+		logger.Printf("signing JWTs with key %s", id)
+	}
+
 	dbConn, err := sql.Open("postgres", cfg.db.dsn)
 	if err != nil {
 		logger.Fatal(err)
@@ -55,10 +105,25 @@ func main() {
 	defer dbConn.Close()
 	logger.Printf("database connection pool established")
 
+	var mail mailer.Mailer = mailer.NewLog(logger, cfg.mailDir, cfg.smtp.sender, cfg.devExposeTokens)
+	if cfg.smtp.host != "" {
+		mail = mailer.NewSMTP(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender)
+	}
+
 	app := &application{
-		config: cfg,
-		logger: logger,
-		models: data.NewModels(dbConn),
+		config:  cfg,
+		logger:  logger,
+		models:  data.NewModels(dbConn),
+		mailer:  mail,
+		keyring: keys,
+	}
+
+	if cfg.adminEmail != "" {
+		err = app.grantAdmin(cfg.adminEmail)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		logger.Printf("granted %s to %s", data.PermissionMembersAdmin, cfg.adminEmail)
 	}
 
 	srv := &http.Server{
@@ -70,3 +135,15 @@ func main() {
 	err = srv.ListenAndServe()
 	logger.Fatal(err)
 }
+
+func splitFiles(val string) []string {
+	var files []string
+
+	for _, file := range strings.Split(val, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
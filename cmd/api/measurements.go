@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+func (app *application) createBodyMeasurementHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Date              string   `json:"date"`
+		Units             string   `json:"units"`
+		Weight            *float64 `json:"weight"`
+		BodyFatPercentage *float64 `json:"body_fat_percentage"`
+		Neck              *float64 `json:"neck"`
+		Chest             *float64 `json:"chest"`
+		Waist             *float64 `json:"waist"`
+		Hips              *float64 `json:"hips"`
+		Arms              *float64 `json:"arms"`
+		Thighs            *float64 `json:"thighs"`
+		Calves            *float64 `json:"calves"`
+		Notes             string   `json:"notes"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	units := app.callerUnits(r)
+	if input.Units != "" {
+		units = input.Units
+	}
+
+	measurement := &data.BodyMeasurement{
+		MemberID:          memberID,
+		Date:              time.Now().UTC().Truncate(24 * time.Hour),
+		Weight:            input.Weight,
+		BodyFatPercentage: input.BodyFatPercentage,
+		Neck:              input.Neck,
+		Chest:             input.Chest,
+		Waist:             input.Waist,
+		Hips:              input.Hips,
+		Arms:              input.Arms,
+		Thighs:            input.Thighs,
+		Calves:            input.Calves,
+		Notes:             input.Notes,
+	}
+
+	v := validator.New()
+
+	if input.Date != "" {
+		measurement.Date, _ = app.parseDate("date", input.Date, v)
+	}
+
+	data.ValidateUnits(v, units)
+	measurement.FromUnits(units)
+
+	if data.ValidateBodyMeasurement(v, measurement); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Measurements.Insert(measurement)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	measurement.ConvertUnits(app.callerUnits(r))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"measurement": measurement}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listBodyMeasurementsHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	var workoutFilters data.WorkoutFilters
+
+	workoutFilters.From = app.readDate(qs, "from", v)
+	workoutFilters.To = app.readDate(qs, "to", v)
+	window := app.readInt(qs, "window", data.DefaultMovingAverageWindow, v)
+
+	filters := app.readFilters(qs, "-date", v)
+	filters.SortSafelist = []string{"date", "-date"}
+
+	data.ValidateWorkoutFilters(v, workoutFilters)
+	data.ValidateMovingAverageWindow(v, window)
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	measurements, metadata, err := app.models.Measurements.GetAll(memberID, workoutFilters, window, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	units := app.callerUnits(r)
+	for _, measurement := range measurements {
+		measurement.ConvertUnits(units)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"measurements": measurements, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteBodyMeasurementHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readNamedIDParam(r, "measurement_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Measurements.Delete(id, memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "measurement successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) logBodyweight(member *data.Member, date time.Time) error {
+	weight := member.Weight
+
+	measurement := &data.BodyMeasurement{
+		MemberID: member.ID,
+		Date:     date.UTC().Truncate(24 * time.Hour),
+		Weight:   &weight,
+	}
+
+	return app.models.Measurements.Insert(measurement)
+}
@@ -25,8 +25,8 @@ func (app *application) getMemberByEmailHandler(w http.ResponseWriter, r *http.R
 			app.notFoundResponse(w, r)
 		default:
 			app.serverErrorResponse(w, r, err)
-			return
 		}
+		return
 	}
 
 	err = app.writeJSON(w, http.StatusOK, envelope{"members": member}, nil)
@@ -36,14 +36,52 @@ func (app *application) getMemberByEmailHandler(w http.ResponseWriter, r *http.R
 	}
 }
 
+func (app *application) listMembersHandler(w http.ResponseWriter, r *http.Request) {
+
+	qs := r.URL.Query()
+
+	name := app.readString(qs, "name", "")
+	email := app.readString(qs, "email", "")
+
+	v := validator.New()
+
+	filters := app.readFilters(qs, "id", v)
+	filters.SortSafelist = []string{"id", "name", "email", "created_at", "-id", "-name", "-email", "-created_at"}
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	members, metadata, err := app.models.Members.GetAll(name, email, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	units := app.callerUnits(r)
+
+	responses := make([]*data.MemberResponse, 0, len(members))
+	for _, member := range members {
+		responses = append(responses, member.Response(units))
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"members": responses, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
 func (app *application) createMemberHandler(w http.ResponseWriter, r *http.Request) {
 
 	var input struct {
-		Email    string `json:"email"`
-		Name     string `json:"name"`
-		Password string `json:"password"`
-		Height   int64  `json:"height"`
-		Weight   int64  `json:"weight"`
+		Email          string  `json:"email"`
+		Name           string  `json:"name"`
+		Password       string  `json:"password"`
+		Height         float64 `json:"height"`
+		Weight         float64 `json:"weight"`
+		PreferredUnits string  `json:"preferred_units"`
 	}
 
 	err := app.readJSON(w, r, &input)
@@ -52,14 +90,19 @@ func (app *application) createMemberHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if input.PreferredUnits == "" {
+		input.PreferredUnits = data.UnitsMetric
+	}
+
 	member := &data.Member{
-		Email:     input.Email,
-		Name:      input.Name,
-		Activated: false,
-		Height:    input.Height,
-		Weight:    input.Weight,
+		Email:          input.Email,
+		Name:           input.Name,
+		Activated:      false,
+		PreferredUnits: input.PreferredUnits,
 	}
 
+	member.SetHeightAndWeight(input.Height, input.Weight, member.PreferredUnits)
+
 	err = member.Password.Set(input.Password)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -75,19 +118,40 @@ func (app *application) createMemberHandler(w http.ResponseWriter, r *http.Reque
 
 	err = app.models.Members.Insert(member)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrDuplicateEmail):
+			v.AddError("email", "a member with this email address already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
-	token, err := app.models.Tokens.New(member.ID, 3*24*time.Hour, "activation")
+	if member.Weight > 0 {
+		err = app.logBodyweight(member, member.CreatedAt)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	token, err := app.models.Tokens.New(member.ID, 3*24*time.Hour, data.ScopeActivation)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	responseEnvelope := envelope{
-		"member":           member,
-		"activation_token": token.Plaintext,
+	app.sendEmail(member.Email, "member_welcome.tmpl", map[string]interface{}{
+		"activationToken": token.Plaintext,
+		"memberID":        member.ID,
+		"name":            member.Name,
+	})
+
+	responseEnvelope := envelope{"member": member.Response(member.PreferredUnits)}
+
+	if app.config.devExposeTokens {
+		responseEnvelope["activation_token"] = token.Plaintext
 	}
 
 	err = app.writeJSON(w, http.StatusCreated, responseEnvelope, nil)
@@ -106,10 +170,11 @@ func (app *application) updateMemberHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 	var input struct {
-		Email  string `json:"email"`
-		Name   string `json:"name"`
-		Height int64  `json:"height"`
-		Weight int64  `json:"weight"`
+		Email          string  `json:"email"`
+		Name           string  `json:"name"`
+		Height         float64 `json:"height"`
+		Weight         float64 `json:"weight"`
+		PreferredUnits string  `json:"preferred_units"`
 	}
 
 	err = app.readJSON(w, r, &input)
@@ -129,18 +194,73 @@ func (app *application) updateMemberHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	member.Email = input.Email
+	previousWeight := member.Weight
+	emailChanged := input.Email != member.Email
+
+	if emailChanged {
+		member.PendingEmail = &input.Email
+	}
+
 	member.Name = input.Name
-	member.Height = input.Height
-	member.Weight = input.Weight
+
+	if input.PreferredUnits != "" {
+		member.PreferredUnits = input.PreferredUnits
+	}
+
+	member.SetHeightAndWeight(input.Height, input.Weight, member.PreferredUnits)
+
+	v := validator.New()
+
+	data.ValidateMember(v, member)
+
+	if emailChanged {
+		data.ValidateEmail(v, input.Email)
+
+		err = app.checkEmailAvailable(v, input.Email)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
 
 	err = app.models.Members.Update(member)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"member": member}, nil)
+	if emailChanged {
+		err = app.sendEmailChangeToken(member)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	if member.Weight > 0 && member.Weight != previousWeight {
+		err = app.logBodyweight(member, time.Now())
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	units := app.callerUnits(r)
+	if member.ID == app.contextGetMember(r).ID {
+		units = member.PreferredUnits
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"member": member.Response(units)}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -152,6 +272,7 @@ func (app *application) deleteMemberHandler(w http.ResponseWriter, r *http.Reque
 	id, err := app.readIDParam(r)
 	if err != nil || id < 1 {
 		app.notFoundResponse(w, r)
+		return
 	}
 
 	err = app.models.Members.Delete(id)
@@ -183,7 +304,7 @@ func (app *application) activateMemberHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	member, err := app.models.Members.GetForToken("activation", input.TokenPlain)
+	member, err := app.models.Members.GetForToken(data.ScopeActivation, input.TokenPlain)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -202,14 +323,277 @@ func (app *application) activateMemberHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	err = app.models.Tokens.DeleteAllForMember("activation", member.ID)
+	err = app.models.Tokens.DeleteAllForMember(data.ScopeActivation, member.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.Permissions.AddForMember(member.ID, data.PermissionExercisesRead)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"member": member.Response(member.PreferredUnits)}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) resetMemberPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Password   string `json:"password"`
+		TokenPlain string `json:"token"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	data.ValidatePassword(v, input.Password)
+	data.ValidateTokenPlaintext(v, input.TokenPlain)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	member, err := app.models.Members.GetForToken(data.ScopePasswordReset, input.TokenPlain)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("token", "invalid or expired password reset token")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = member.Password.Set(input.Password)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.Members.Update(member)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Tokens.DeleteAllForMember(data.ScopePasswordReset, member.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.Sessions.DeleteAllForMember(member.ID, 0)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.sendEmail(member.Email, "password_changed.tmpl", map[string]interface{}{"name": member.Name})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "your password was successfully reset"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateMemberPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		CurrentPassword string `json:"current_password"`
+		Password        string `json:"password"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(input.CurrentPassword != "", "current_password", "must be provided")
+	data.ValidatePassword(v, input.Password)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	member, err := app.models.Members.GetById(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	match, err := member.Password.Compare(input.CurrentPassword)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !match {
+		v.AddError("current_password", "is incorrect")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = member.Password.Set(input.Password)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.Members.Update(member)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Tokens.DeleteAllForMember(data.ScopePasswordReset, member.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.Sessions.DeleteAllForMember(member.ID, app.currentSessionFor(r, member.ID))
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"member": member}, nil)
+	app.sendEmail(member.Email, "password_changed.tmpl", map[string]interface{}{"name": member.Name})
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "your password was successfully changed"}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+func (app *application) confirmMemberEmailHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		TokenPlain string `json:"token"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateTokenPlaintext(v, input.TokenPlain); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	member, err := app.models.Members.GetForToken(data.ScopeEmailChange, input.TokenPlain)
+	if err != nil && !errors.Is(err, data.ErrRecordNotFound) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if member == nil || member.ID != id || member.PendingEmail == nil {
+		v.AddError("token", "invalid or expired email change token")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	member.Email = *member.PendingEmail
+	member.PendingEmail = nil
+
+	err = app.models.Members.Update(member)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateEmail):
+			v.AddError("email", "a member with this email address already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Tokens.DeleteAllForMember(data.ScopeEmailChange, member.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"member": member.Response(member.PreferredUnits)}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) checkEmailAvailable(v *validator.Validator, email string) error {
+	_, err := app.models.Members.GetByEmail(email)
+	switch {
+	case err == nil:
+		v.AddError("email", "a member with this email address already exists")
+	case !errors.Is(err, data.ErrRecordNotFound):
+		return err
+	}
+
+	return nil
+}
+
+func (app *application) sendEmailChangeToken(member *data.Member) error {
+	err := app.models.Tokens.DeleteAllForMember(data.ScopeEmailChange, member.ID)
+	if err != nil {
+		return err
+	}
+
+	token, err := app.models.Tokens.New(member.ID, 24*time.Hour, data.ScopeEmailChange)
+	if err != nil {
+		return err
+	}
+
+	app.sendEmail(*member.PendingEmail, "email_change.tmpl", map[string]interface{}{
+		"emailChangeToken": token.Plaintext,
+		"email":            *member.PendingEmail,
+		"memberID":         member.ID,
+		"name":             member.Name,
+	})
+
+	return nil
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/pascaldekloe/jwt"
 	"golang.org/x/time/rate"
 	"workout-tracker-go.ilijakrilovic.com/internal/data"
 )
@@ -32,7 +31,7 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 
 		token := headerParts[1]
 
-		claims, err := jwt.HMACCheck([]byte(token), []byte(app.config.jwt.secret))
+		claims, err := app.keyring.Check([]byte(token))
 		if err != nil {
 			app.invalidAuthenticationTokenResponse(w, r)
 			return
@@ -59,6 +58,25 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 			return
 		}
 
+		sid, _ := claims.String("sid")
+
+		sessionID, err := strconv.ParseInt(sid, 10, 64)
+		if err != nil {
+			app.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
+		active, err := app.models.Sessions.IsActive(sessionID, memberID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if !active {
+			app.invalidAuthenticationTokenResponse(w, r)
+			return
+		}
+
 		member, err := app.models.Members.GetById(memberID)
 		if err != nil {
 			switch {
@@ -71,6 +89,7 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 		}
 
 		r = app.contextSetMember(r, member)
+		r = app.contextSetSessionID(r, sessionID)
 
 		next.ServeHTTP(w, r)
 	})
@@ -90,7 +109,7 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 	})
 }
 
-func (app *application) requireActivatedMember(next http.HandlerFunc) http.HandlerFunc {
+func (app *application) requireAuthenticatedMember(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		member := app.contextGetMember(r)
 
@@ -99,11 +118,71 @@ func (app *application) requireActivatedMember(next http.HandlerFunc) http.Handl
 			return
 		}
 
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (app *application) requireActivatedMember(next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		member := app.contextGetMember(r)
+
 		if !member.Activated {
 			app.inactiveAccountResponse(w, r)
 			return
 		}
 
 		next.ServeHTTP(w, r)
-	})
+	}
+
+	return app.requireAuthenticatedMember(fn)
+}
+
+func (app *application) requireOwnerOrAdmin(next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		member := app.contextGetMember(r)
+
+		id, err := app.readIDParam(r)
+		if err != nil || id < 1 {
+			app.notFoundResponse(w, r)
+			return
+		}
+
+		if member.ID != id {
+			permissions, err := app.models.Permissions.GetAllForMember(member.ID)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+
+			if !permissions.Include(data.PermissionMembersAdmin) {
+				app.notPermittedResponse(w, r)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return app.requireActivatedMember(fn)
+}
+
+func (app *application) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		member := app.contextGetMember(r)
+
+		permissions, err := app.models.Permissions.GetAllForMember(member.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if !permissions.Include(code) {
+			app.notPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return app.requireActivatedMember(fn)
 }
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+type programInput struct {
+	Name             string             `json:"name"`
+	Description      string             `json:"description"`
+	Progression      string             `json:"progression"`
+	Increment        *float64           `json:"increment"`
+	DeloadPercentage *float64           `json:"deload_percentage"`
+	Unit             string             `json:"unit"`
+	Days             []*data.ProgramDay `json:"days"`
+}
+
+func (input programInput) unit(weightUnit string) string {
+	if input.Unit == "" {
+		return weightUnit
+	}
+	return input.Unit
+}
+
+func (input programInput) copyTo(program *data.Program, weightUnit string) {
+	program.Name = strings.TrimSpace(input.Name)
+	program.Description = input.Description
+	program.Progression = input.Progression
+	program.Days = input.Days
+
+	if program.Progression == "" {
+		program.Progression = data.ProgressionNone
+	}
+
+	if input.Increment != nil {
+		program.Increment = data.ToKilograms(*input.Increment, input.unit(weightUnit))
+	}
+
+	if input.DeloadPercentage != nil {
+		program.DeloadPercentage = *input.DeloadPercentage
+	}
+}
+
+func (app *application) createProgramHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input programInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+
+	program := &data.Program{
+		MemberID:         memberID,
+		Increment:        data.DefaultProgramIncrement,
+		DeloadPercentage: data.DefaultDeloadPercentage,
+	}
+	input.copyTo(program, weightUnit)
+
+	v := validator.New()
+
+	data.ValidateWeightUnit(v, input.unit(weightUnit))
+
+	if data.ValidateProgram(v, program); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Programs.Insert(program)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidRoutine):
+			app.failedValidationResponse(w, r, map[string]string{"days": "must only reference the member's own routines"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	program.ConvertWeights(weightUnit)
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"program": program}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listProgramsHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	filters := app.readFilters(r.URL.Query(), "id", v)
+	filters.SortSafelist = []string{"id", "name", "created_at", "-id", "-name", "-created_at"}
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	programs, metadata, err := app.models.Programs.GetAllForMember(memberID, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+	for _, program := range programs {
+		program.ConvertWeights(weightUnit)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"programs": programs, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getProgramHandler(w http.ResponseWriter, r *http.Request) {
+
+	program, ok := app.readProgram(w, r)
+	if !ok {
+		return
+	}
+
+	program.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"program": program}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateProgramHandler(w http.ResponseWriter, r *http.Request) {
+
+	program, ok := app.readProgram(w, r)
+	if !ok {
+		return
+	}
+
+	var input programInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+
+	input.copyTo(program, weightUnit)
+
+	v := validator.New()
+
+	data.ValidateWeightUnit(v, input.unit(weightUnit))
+
+	if data.ValidateProgram(v, program); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Programs.Update(program)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		case errors.Is(err, data.ErrInvalidRoutine):
+			app.failedValidationResponse(w, r, map[string]string{"days": "must only reference the member's own routines"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	program.ConvertWeights(weightUnit)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"program": program}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteProgramHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readNamedIDParam(r, "program_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Programs.Delete(id, memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "program successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) enrollProgramHandler(w http.ResponseWriter, r *http.Request) {
+
+	program, ok := app.readProgram(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		StartedAt *time.Time `json:"started_at"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil && !errors.Is(err, io.EOF) {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	enrollment := &data.Enrollment{
+		MemberID:  program.MemberID,
+		ProgramID: program.ID,
+		StartedAt: time.Now().UTC().Truncate(time.Second),
+		Program:   program,
+	}
+
+	if input.StartedAt != nil {
+		enrollment.StartedAt = *input.StartedAt
+	}
+
+	err = app.models.Programs.Enroll(enrollment)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	program.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"enrollment": enrollment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getEnrollmentHandler(w http.ResponseWriter, r *http.Request) {
+
+	enrollment, ok := app.readEnrollment(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment.Program.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"enrollment": enrollment}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) unenrollProgramHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Programs.Unenroll(memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "successfully left the program"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getTodaysSessionHandler(w http.ResponseWriter, r *http.Request) {
+
+	enrollment, ok := app.readEnrollment(w, r)
+	if !ok {
+		return
+	}
+
+	session, _, ok := app.prescribeToday(w, r, enrollment)
+	if !ok {
+		return
+	}
+
+	session.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"session": session}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) startTodaysSessionHandler(w http.ResponseWriter, r *http.Request) {
+
+	enrollment, ok := app.readEnrollment(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Date  *time.Time `json:"date"`
+		Notes *string    `json:"notes"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil && !errors.Is(err, io.EOF) {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	session, routine, ok := app.prescribeToday(w, r, enrollment)
+	if !ok {
+		return
+	}
+
+	workout := &data.Workout{
+		MemberID:  enrollment.MemberID,
+		Date:      time.Now().UTC().Truncate(time.Second),
+		Notes:     routine.Notes,
+		ProgramID: &enrollment.ProgramID,
+		Details:   session.Details(),
+	}
+
+	if input.Date != nil {
+		workout.Date = *input.Date
+	}
+
+	if input.Notes != nil {
+		workout.Notes = *input.Notes
+	}
+
+	v := validator.New()
+
+	data.ValidateWorkout(v, workout.Date, workout.Notes)
+	v.Check(!workout.Date.Before(enrollment.StartedAt), "date", "must not be before the program was started")
+	v.Check(len(workout.Details) > 0, "sets", "session must contain at least one set")
+
+	err = app.validateWorkoutDetails(v, workout.MemberID, workout.Details, true)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Workouts.Insert(workout)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidExercise):
+			app.failedValidationResponse(w, r, map[string]string{"sets": "must only reference existing exercises available to the member"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	workout.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"workout": workout}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) prescribeToday(w http.ResponseWriter, r *http.Request, enrollment *data.Enrollment) (*data.PrescribedSession, *data.Routine, bool) {
+	completed, err := app.models.Programs.CountSessions(enrollment)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return nil, nil, false
+	}
+
+	day, cycle := enrollment.Program.DayAt(completed)
+	if day == nil {
+		app.notFoundResponse(w, r)
+		return nil, nil, false
+	}
+
+	routine, err := app.models.Routines.Get(day.RoutineID, enrollment.MemberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, nil, false
+	}
+
+	session, err := app.models.Programs.Prescribe(enrollment, day, cycle, routine, data.WeightUnit(app.callerUnits(r)))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return nil, nil, false
+	}
+
+	return session, routine, true
+}
+
+func (app *application) readProgram(w http.ResponseWriter, r *http.Request) (*data.Program, bool) {
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	id, err := app.readNamedIDParam(r, "program_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	program, err := app.models.Programs.Get(id, memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return program, true
+}
+
+func (app *application) readEnrollment(w http.ResponseWriter, r *http.Request) (*data.Enrollment, bool) {
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	enrollment, err := app.models.Programs.GetEnrollment(memberID)
+	if err == nil {
+		enrollment.Program, err = app.models.Programs.Get(enrollment.ProgramID, memberID)
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return enrollment, true
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+func (app *application) listPersonalRecordsHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	formula := app.readString(r.URL.Query(), "formula", data.FormulaEpley)
+
+	v := validator.New()
+
+	if data.ValidateFormula(v, formula); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	records, err := app.models.Records.GetCurrent(memberID, 0, formula)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+	for _, record := range records {
+		record.ConvertWeights(weightUnit)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"records": records, "weight_unit": weightUnit}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getExercisePersonalRecordsHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	exerciseID, err := app.readNamedIDParam(r, "exercise_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	formula := app.readString(r.URL.Query(), "formula", data.FormulaEpley)
+
+	v := validator.New()
+
+	if data.ValidateFormula(v, formula); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	exercise, err := app.models.Exercises.GetById(exerciseID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if exercise.IsPrivate() && !exercise.IsOwnedBy(memberID) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	records, err := app.models.Records.GetCurrent(memberID, exerciseID, formula)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	history, err := app.models.Records.GetHistory(memberID, exerciseID, formula)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+	for _, record := range records {
+		record.ConvertWeights(weightUnit)
+	}
+	for _, record := range history {
+		record.ConvertWeights(weightUnit)
+	}
+
+	responseEnvelope := envelope{
+		"exercise":    exercise,
+		"records":     records,
+		"history":     history,
+		"weight_unit": weightUnit,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, responseEnvelope, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -4,29 +4,97 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
 )
 
 func (app *application) routes() http.Handler {
 	router := httprouter.New()
 
 	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", app.healthcheckHandler)
+	router.HandlerFunc(http.MethodGet, "/.well-known/jwks.json", app.jwksHandler)
 
+	router.HandlerFunc(http.MethodGet, "/v1/admin/members", app.requirePermission(data.PermissionMembersAdmin, app.listMembersHandler))
 	router.HandlerFunc(http.MethodGet, "/v1/members", app.getMemberByEmailHandler)
 	router.HandlerFunc(http.MethodPost, "/v1/members", app.createMemberHandler)
-	router.HandlerFunc(http.MethodPut, "/v1/members/:id", app.updateMemberHandler)
-	router.HandlerFunc(http.MethodDelete, "/v1/members/:id", app.deleteMemberHandler)
+	router.HandlerFunc(http.MethodPut, "/v1/members/:id", app.requireOwnerOrAdmin(app.updateMemberHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id", app.requireOwnerOrAdmin(app.deleteMemberHandler))
 	router.HandlerFunc(http.MethodPut, "/v1/members/:id/activate", app.activateMemberHandler)
+	router.HandlerFunc(http.MethodPut, "/v1/members/:id/password", app.requireOwnerOrAdmin(app.updateMemberPasswordHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/members/:id/email", app.confirmMemberEmailHandler)
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/sessions", app.requireOwnerOrAdmin(app.listSessionsHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/sessions", app.requireOwnerOrAdmin(app.deleteOtherSessionsHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/sessions/:session_id", app.requireOwnerOrAdmin(app.deleteSessionHandler))
 
-	router.HandlerFunc(http.MethodPost, "/v1/exercises", app.requireActivatedMember(app.createExerciseHandler))
-	router.HandlerFunc(http.MethodGet, "/v1/exercises", app.requireActivatedMember(app.getExercisesByCategoryHandler))
-	router.HandlerFunc(http.MethodPut, "/v1/exercises/:id", app.requireActivatedMember(app.updateExerciseHandler))
-	router.HandlerFunc(http.MethodDelete, "/v1/exercises/:id", app.requireActivatedMember(app.deleteExerciseHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/permissions", app.requirePermission(data.PermissionMembersAdmin, app.getMemberPermissionsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/permissions", app.requirePermission(data.PermissionMembersAdmin, app.grantMemberPermissionsHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/permissions", app.requirePermission(data.PermissionMembersAdmin, app.revokeMemberPermissionsHandler))
 
-	router.HandlerFunc(http.MethodPost, "/v1/members/:id/workouts", app.requireActivatedMember(app.createWorkoutHandler))
-	router.HandlerFunc(http.MethodGet, "/v1/members/:id/workouts", app.requireActivatedMember(app.getAllWorkoutsByMemberIDHandler))
-	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/workouts/:workout_id", app.requireActivatedMember(app.deleteWorkoutHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/exercises", app.requirePermission(data.PermissionExercisesWrite, app.createExerciseHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/exercises", app.requirePermission(data.PermissionExercisesRead, app.listExercisesHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/exercises/:id", app.requirePermission(data.PermissionExercisesWrite, app.updateExerciseHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/exercises/:id", app.requirePermission(data.PermissionExercisesWrite, app.deleteExerciseHandler))
+
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/exercises", app.requireOwnerOrAdmin(app.createMemberExerciseHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/members/:id/exercises/:exercise_id", app.requireOwnerOrAdmin(app.updateMemberExerciseHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/exercises/:exercise_id", app.requireOwnerOrAdmin(app.deleteMemberExerciseHandler))
+
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/workouts", app.requireOwnerOrAdmin(app.createWorkoutHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/workouts", app.requireOwnerOrAdmin(app.getAllWorkoutsByMemberIDHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/workouts/:workout_id", app.requireOwnerOrAdmin(app.getWorkoutHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/members/:id/workouts/:workout_id", app.requireOwnerOrAdmin(app.updateWorkoutHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/workouts/:workout_id", app.requireOwnerOrAdmin(app.deleteWorkoutHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/workouts/:workout_id/details", app.requireOwnerOrAdmin(app.createWorkoutDetailHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/members/:id/workouts/:workout_id/details/order", app.requireOwnerOrAdmin(app.reorderWorkoutDetailsHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/members/:id/workouts/:workout_id/details/:detail_id", app.requireOwnerOrAdmin(app.updateWorkoutDetailHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/workouts/:workout_id/details/:detail_id", app.requireOwnerOrAdmin(app.deleteWorkoutDetailHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/export/workouts", app.requireOwnerOrAdmin(app.exportWorkoutsHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/import/workouts", app.requireOwnerOrAdmin(app.importWorkoutsHandler))
+
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/routines", app.requireOwnerOrAdmin(app.createRoutineHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/routines", app.requireOwnerOrAdmin(app.listRoutinesHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/routines/:routine_id", app.requireOwnerOrAdmin(app.getRoutineHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/members/:id/routines/:routine_id", app.requireOwnerOrAdmin(app.updateRoutineHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/routines/:routine_id", app.requireOwnerOrAdmin(app.deleteRoutineHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/routines/:routine_id/start", app.requireOwnerOrAdmin(app.startRoutineHandler))
+
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/programs", app.requireOwnerOrAdmin(app.createProgramHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/programs", app.requireOwnerOrAdmin(app.listProgramsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/programs/:program_id", app.requireOwnerOrAdmin(app.getProgramHandler))
+	router.HandlerFunc(http.MethodPut, "/v1/members/:id/programs/:program_id", app.requireOwnerOrAdmin(app.updateProgramHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/programs/:program_id", app.requireOwnerOrAdmin(app.deleteProgramHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/programs/:program_id/enroll", app.requireOwnerOrAdmin(app.enrollProgramHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/program", app.requireOwnerOrAdmin(app.getEnrollmentHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/program", app.requireOwnerOrAdmin(app.unenrollProgramHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/program/today", app.requireOwnerOrAdmin(app.getTodaysSessionHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/program/today/start", app.requireOwnerOrAdmin(app.startTodaysSessionHandler))
+
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/schedule", app.requireOwnerOrAdmin(app.createScheduledWorkoutHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/schedule", app.requireOwnerOrAdmin(app.listScheduledWorkoutsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/schedule/:scheduled_id", app.requireOwnerOrAdmin(app.getScheduledWorkoutHandler))
+	router.HandlerFunc(http.MethodPatch, "/v1/members/:id/schedule/:scheduled_id", app.requireOwnerOrAdmin(app.updateScheduledWorkoutHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/schedule/:scheduled_id", app.requireOwnerOrAdmin(app.deleteScheduledWorkoutHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/schedule/:scheduled_id/complete", app.requireOwnerOrAdmin(app.completeScheduledWorkoutHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/schedule/:scheduled_id/skip", app.requireOwnerOrAdmin(app.skipScheduledWorkoutHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/calendar", app.requireOwnerOrAdmin(app.getCalendarHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/calendar.ics", app.getCalendarFeedHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/calendar/token", app.requireOwnerOrAdmin(app.createCalendarTokenHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/calendar/token", app.requireOwnerOrAdmin(app.deleteCalendarTokenHandler))
+
+	router.HandlerFunc(http.MethodPost, "/v1/members/:id/measurements", app.requireOwnerOrAdmin(app.createBodyMeasurementHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/measurements", app.requireOwnerOrAdmin(app.listBodyMeasurementsHandler))
+	router.HandlerFunc(http.MethodDelete, "/v1/members/:id/measurements/:measurement_id", app.requireOwnerOrAdmin(app.deleteBodyMeasurementHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/records", app.requireOwnerOrAdmin(app.listPersonalRecordsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/records/:exercise_id", app.requireOwnerOrAdmin(app.getExercisePersonalRecordsHandler))
+
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/stats", app.requireOwnerOrAdmin(app.getStatsHandler))
+	router.HandlerFunc(http.MethodGet, "/v1/members/:id/stats/exercises/:exercise_id", app.requireOwnerOrAdmin(app.getExerciseStatsHandler))
 
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/authentication", app.createAuthenticationTokenHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/refresh", app.refreshAuthenticationTokenHandler)
+	router.HandlerFunc(http.MethodDelete, "/v1/tokens", app.requireAuthenticatedMember(app.deleteAuthenticationTokenHandler))
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/password-reset", app.createPasswordResetTokenHandler)
+	router.HandlerFunc(http.MethodPut, "/v1/tokens/password-reset", app.resetMemberPasswordHandler)
 
 	return app.authenticate(app.rateLimit(router))
 }
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+type routineInput struct {
+	Name      string                  `json:"name"`
+	Notes     string                  `json:"notes"`
+	Unit      string                  `json:"unit"`
+	Exercises []*data.RoutineExercise `json:"exercises"`
+}
+
+func (app *application) createRoutineHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input routineInput
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	routine := &data.Routine{MemberID: memberID}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+
+	v := validator.New()
+
+	err = app.applyRoutineInput(v, routine, input, weightUnit)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Routines.Insert(routine)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidExercise):
+			app.failedValidationResponse(w, r, map[string]string{"exercises": "must only reference existing exercises available to the member"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	routine.ConvertWeights(weightUnit)
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"routine": routine}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listRoutinesHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	qs := r.URL.Query()
+
+	name := app.readString(qs, "name", "")
+
+	v := validator.New()
+
+	filters := app.readFilters(qs, "id", v)
+	filters.SortSafelist = []string{"id", "name", "created_at", "-id", "-name", "-created_at"}
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	routines, metadata, err := app.models.Routines.GetAllForMember(memberID, name, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+	for _, routine := range routines {
+		routine.ConvertWeights(weightUnit)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"routines": routines, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getRoutineHandler(w http.ResponseWriter, r *http.Request) {
+
+	routine, ok := app.readRoutine(w, r)
+	if !ok {
+		return
+	}
+
+	routine.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"routine": routine}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateRoutineHandler(w http.ResponseWriter, r *http.Request) {
+
+	routine, ok := app.readRoutine(w, r)
+	if !ok {
+		return
+	}
+
+	var input routineInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+
+	v := validator.New()
+
+	err = app.applyRoutineInput(v, routine, input, weightUnit)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Routines.Update(routine)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		case errors.Is(err, data.ErrInvalidExercise):
+			app.failedValidationResponse(w, r, map[string]string{"exercises": "must only reference existing exercises available to the member"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	routine.ConvertWeights(weightUnit)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"routine": routine}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteRoutineHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readNamedIDParam(r, "routine_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Routines.Delete(id, memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "routine successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) startRoutineHandler(w http.ResponseWriter, r *http.Request) {
+
+	routine, ok := app.readRoutine(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Date  *time.Time `json:"date"`
+		Notes *string    `json:"notes"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil && !errors.Is(err, io.EOF) {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	formula := app.readString(r.URL.Query(), "formula", data.FormulaEpley)
+
+	workout := &data.Workout{
+		MemberID: routine.MemberID,
+		Date:     time.Now().UTC().Truncate(time.Second),
+		Notes:    routine.Notes,
+		Details:  routine.Details(),
+	}
+
+	if input.Date != nil {
+		workout.Date = *input.Date
+	}
+
+	if input.Notes != nil {
+		workout.Notes = *input.Notes
+	}
+
+	v := validator.New()
+
+	data.ValidateWorkout(v, workout.Date, workout.Notes)
+	data.ValidateFormula(v, formula)
+	v.Check(len(workout.Details) > 0, "exercises", "routine must contain at least one exercise")
+
+	err = app.validateWorkoutDetails(v, workout.MemberID, workout.Details, true)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Workouts.Insert(workout)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidExercise):
+			app.failedValidationResponse(w, r, map[string]string{"exercises": "must only reference existing exercises available to the member"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	workout.NewRecords = data.FilterRecordsByFormula(workout.NewRecords, formula)
+	workout.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"workout": workout}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readRoutine(w http.ResponseWriter, r *http.Request) (*data.Routine, bool) {
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	id, err := app.readNamedIDParam(r, "routine_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	routine, err := app.models.Routines.Get(id, memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return routine, true
+}
+
+func (app *application) applyRoutineInput(v *validator.Validator, routine *data.Routine, input routineInput, weightUnit string) error {
+	if input.Unit == "" {
+		input.Unit = weightUnit
+	}
+
+	routine.Name = strings.TrimSpace(input.Name)
+	routine.Notes = input.Notes
+	routine.Exercises = input.Exercises
+
+	data.ValidateRoutine(v, routine)
+	data.ValidateWeightUnit(v, input.Unit)
+
+	exerciseIDs := []int64{}
+	for _, exercise := range routine.Exercises {
+		if exercise != nil {
+			exercise.TargetWeight = data.ToKilograms(exercise.TargetWeight, input.Unit)
+			exerciseIDs = append(exerciseIDs, exercise.ExerciseID)
+		}
+	}
+
+	measurementTypes, err := app.models.Exercises.GetMeasurementTypes(exerciseIDs, routine.MemberID)
+	if err != nil {
+		return err
+	}
+
+	for i, exercise := range routine.Exercises {
+		prefix := fmt.Sprintf("exercises[%d].", i)
+
+		if exercise == nil {
+			v.AddError(strings.TrimSuffix(prefix, "."), "must be provided")
+			continue
+		}
+
+		measurementType, ok := measurementTypes[exercise.ExerciseID]
+		if !ok {
+			v.AddError(prefix+"exercise_id", "must reference an existing exercise available to the member")
+			continue
+		}
+
+		data.ValidateRoutineExercise(v, prefix, exercise, measurementType)
+	}
+
+	return nil
+}
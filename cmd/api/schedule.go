@@ -0,0 +1,376 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+func (app *application) createScheduledWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Date      string `json:"date"`
+		Title     string `json:"title"`
+		Notes     string `json:"notes"`
+		RoutineID *int64 `json:"routine_id"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	scheduled := &data.ScheduledWorkout{
+		MemberID:  memberID,
+		Title:     strings.TrimSpace(input.Title),
+		Notes:     input.Notes,
+		RoutineID: input.RoutineID,
+		Status:    data.ScheduleStatusPlanned,
+	}
+
+	v := validator.New()
+
+	if input.Date != "" {
+		scheduled.Date, _ = app.parseDate("date", input.Date, v)
+	}
+
+	err = app.checkScheduledRoutine(v, scheduled)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if data.ValidateScheduledWorkout(v, scheduled); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Schedule.Insert(scheduled)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"scheduled_workout": scheduled}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listScheduledWorkoutsHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	var workoutFilters data.WorkoutFilters
+
+	workoutFilters.From = app.readDate(qs, "from", v)
+	workoutFilters.To = app.readDate(qs, "to", v)
+	status := strings.ToLower(app.readString(qs, "status", ""))
+
+	filters := app.readFilters(qs, "date", v)
+	filters.SortSafelist = []string{"date", "id", "-date", "-id"}
+
+	data.ValidateWorkoutFilters(v, workoutFilters)
+	data.ValidateScheduleStatus(v, status)
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	schedule, metadata, err := app.models.Schedule.GetAll(memberID, workoutFilters, status, filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"scheduled_workouts": schedule, "metadata": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getScheduledWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+
+	scheduled, ok := app.readScheduledWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"scheduled_workout": scheduled}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateScheduledWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+
+	scheduled, ok := app.readScheduledWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Date      *string `json:"date"`
+		Title     *string `json:"title"`
+		Notes     *string `json:"notes"`
+		RoutineID *int64  `json:"routine_id"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if input.Date != nil {
+		date, ok := app.parseDate("date", *input.Date, v)
+		if ok && !date.Equal(scheduled.Date) {
+			v.Check(scheduled.Status != data.ScheduleStatusCompleted, "date", "cannot reschedule a completed workout")
+			scheduled.Date = date
+			scheduled.Status = data.ScheduleStatusPlanned
+		}
+	}
+
+	if input.Title != nil {
+		scheduled.Title = strings.TrimSpace(*input.Title)
+	}
+
+	if input.Notes != nil {
+		scheduled.Notes = *input.Notes
+	}
+
+	if input.RoutineID != nil {
+		scheduled.RoutineID = input.RoutineID
+	}
+
+	err = app.checkScheduledRoutine(v, scheduled)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if data.ValidateScheduledWorkout(v, scheduled); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	app.saveScheduledWorkout(w, r, scheduled)
+}
+
+func (app *application) completeScheduledWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+
+	scheduled, ok := app.readScheduledWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		WorkoutID int64 `json:"workout_id"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if v.Check(input.WorkoutID > 0, "workout_id", "must be provided"); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Workouts.Get(input.WorkoutID, scheduled.MemberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.failedValidationResponse(w, r, map[string]string{"workout_id": "must reference one of the member's workouts"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	scheduled.Status = data.ScheduleStatusCompleted
+	scheduled.WorkoutID = &input.WorkoutID
+
+	app.saveScheduledWorkout(w, r, scheduled)
+}
+
+func (app *application) skipScheduledWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+
+	scheduled, ok := app.readScheduledWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	scheduled.Status = data.ScheduleStatusSkipped
+	scheduled.WorkoutID = nil
+
+	app.saveScheduledWorkout(w, r, scheduled)
+}
+
+func (app *application) deleteScheduledWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := app.readNamedIDParam(r, "scheduled_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Schedule.Delete(id, memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "scheduled workout successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getCalendarHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, 1-today.Day())
+	to := from.AddDate(0, 1, -1)
+
+	if date := app.readDate(qs, "from", v); date != nil {
+		from = *date
+	}
+
+	if date := app.readDate(qs, "to", v); date != nil {
+		to = *date
+	}
+
+	v.Check(!to.Before(from), "to", "must not be before from")
+	v.Check(!to.After(from.AddDate(1, 0, 0)), "to", "must not be more than a year after from")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	calendar, err := app.models.Schedule.GetCalendar(memberID, from, to, today)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"calendar": calendar}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readScheduledWorkout(w http.ResponseWriter, r *http.Request) (*data.ScheduledWorkout, bool) {
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	id, err := app.readNamedIDParam(r, "scheduled_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	scheduled, err := app.models.Schedule.Get(id, memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return scheduled, true
+}
+
+func (app *application) checkScheduledRoutine(v *validator.Validator, scheduled *data.ScheduledWorkout) error {
+	if scheduled.RoutineID == nil {
+		v.Check(scheduled.Title != "", "title", "must be provided when no routine is given")
+		return nil
+	}
+
+	routine, err := app.models.Routines.Get(*scheduled.RoutineID, scheduled.MemberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("routine_id", "must reference one of the member's routines")
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if scheduled.Title == "" {
+		scheduled.Title = routine.Name
+	}
+
+	return nil
+}
+
+func (app *application) saveScheduledWorkout(w http.ResponseWriter, r *http.Request, scheduled *data.ScheduledWorkout) {
+	err := app.models.Schedule.Update(scheduled)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"scheduled_workout": scheduled}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
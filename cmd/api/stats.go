@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+func (app *application) getStatsHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	var workoutFilters data.WorkoutFilters
+
+	workoutFilters.From = app.readDate(qs, "from", v)
+	workoutFilters.To = app.readDate(qs, "to", v)
+	period := strings.ToLower(app.readString(qs, "period", data.PeriodWeek))
+
+	data.ValidatePeriod(v, period)
+
+	if data.ValidateWorkoutFilters(v, workoutFilters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	stats, err := app.models.Stats.Get(memberID, workoutFilters, period)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	stats.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"stats": stats}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getExerciseStatsHandler(w http.ResponseWriter, r *http.Request) {
+
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	exerciseID, err := app.readNamedIDParam(r, "exercise_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+
+	var workoutFilters data.WorkoutFilters
+
+	workoutFilters.From = app.readDate(qs, "from", v)
+	workoutFilters.To = app.readDate(qs, "to", v)
+	formula := app.readString(qs, "formula", data.FormulaEpley)
+
+	data.ValidateFormula(v, formula)
+
+	if data.ValidateWorkoutFilters(v, workoutFilters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	exercise, err := app.models.Exercises.GetById(exerciseID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if exercise.IsPrivate() && !exercise.IsOwnedBy(memberID) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	series, err := app.models.Stats.GetExerciseProgress(memberID, exerciseID, workoutFilters, formula)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+	for _, point := range series {
+		point.ConvertWeights(weightUnit)
+	}
+
+	responseEnvelope := envelope{
+		"exercise":    exercise,
+		"formula":     formula,
+		"weight_unit": weightUnit,
+		"series":      series,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, responseEnvelope, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
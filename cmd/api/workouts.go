@@ -2,40 +2,90 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/julienschmidt/httprouter"
 	"workout-tracker-go.ilijakrilovic.com/internal/data"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
 )
 
 func (app *application) createWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
 	var input struct {
-		MemberID int64                 `json:"member_id"`
-		Date     time.Time             `json:"date"`
-		Details  []*data.WorkoutDetail `json:"details"`
+		Date    time.Time             `json:"date"`
+		Notes   string                `json:"notes"`
+		Unit    string                `json:"unit"`
+		Details []*data.WorkoutDetail `json:"details"`
 	}
 
-	err := app.readJSON(w, r, &input)
+	err = app.readJSON(w, r, &input)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
 
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+
+	if input.Unit == "" {
+		input.Unit = weightUnit
+	}
+
+	formula := app.readString(r.URL.Query(), "formula", data.FormulaEpley)
+
 	workout := &data.Workout{
-		MemberID: input.MemberID,
+		MemberID: memberID,
 		Date:     input.Date,
+		Notes:    input.Notes,
 		Details:  input.Details,
 	}
 
-	err = app.models.Workouts.Insert(workout)
+	for _, detail := range workout.Details {
+		if detail != nil {
+			detail.Weight = data.ToKilograms(detail.Weight, input.Unit)
+		}
+	}
+
+	v := validator.New()
+
+	data.ValidateWorkout(v, workout.Date, workout.Notes)
+	data.ValidateWeightUnit(v, input.Unit)
+	data.ValidateFormula(v, formula)
+	v.Check(len(workout.Details) > 0, "details", "must contain at least one set")
+
+	err = app.validateWorkoutDetails(v, memberID, workout.Details, true)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Workouts.Insert(workout)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidExercise):
+			app.failedValidationResponse(w, r, map[string]string{"details": "must only reference existing exercises available to the member"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	workout.NewRecords = data.FilterRecordsByFormula(workout.NewRecords, formula)
+	workout.ConvertWeights(weightUnit)
+
 	err = app.writeJSON(w, http.StatusCreated, envelope{"workout": workout}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
@@ -49,13 +99,40 @@ func (app *application) getAllWorkoutsByMemberIDHandler(w http.ResponseWriter, r
 		return
 	}
 
-	workouts, err := app.models.Workouts.GetByMemberID(memberID)
+	v := validator.New()
+	qs := r.URL.Query()
+
+	var workoutFilters data.WorkoutFilters
+
+	workoutFilters.From = app.readDate(qs, "from", v)
+	workoutFilters.To = app.readDate(qs, "to", v)
+	workoutFilters.ExerciseID = int64(app.readInt(qs, "exercise_id", 0, v))
+	workoutFilters.Category = strings.ToLower(app.readString(qs, "category", ""))
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+
+	workoutFilters.MinWeight = app.readWeight(qs, "min_weight", weightUnit, v)
+
+	filters := app.readFilters(qs, "-date", v)
+	filters.SortSafelist = []string{"date", "id", "-date", "-id"}
+
+	data.ValidateWorkoutFilters(v, workoutFilters)
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	workouts, metadata, err := app.models.Workouts.GetByMemberID(memberID, workoutFilters, filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"workouts": workouts}, nil)
+	for _, workout := range workouts {
+		workout.ConvertWeights(weightUnit)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"workouts": workouts, "metadata": metadata}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
@@ -63,17 +140,346 @@ func (app *application) getAllWorkoutsByMemberIDHandler(w http.ResponseWriter, r
 
 func (app *application) deleteWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 
-	id := httprouter.ParamsFromContext(r.Context()).ByName("workout_id")
+	workout, ok := app.readWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	if !app.checkWorkoutVersion(w, r, nil, workout) {
+		return
+	}
+
+	err := app.models.Workouts.Delete(workout)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "workout sucessfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) getWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+
+	workout, ok := app.readWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	workout.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
 
-	idInt, err := strconv.ParseInt(id, 10, 64)
+	err := app.writeJSON(w, http.StatusOK, envelope{"workout": workout}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+
+	workout, ok := app.readWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Date    *time.Time `json:"date"`
+		Notes   *string    `json:"notes"`
+		Version *int       `json:"version"`
+	}
+
+	err := app.readJSON(w, r, &input)
 	if err != nil {
 		app.badRequestResponse(w, r, err)
 		return
 	}
 
-	err = app.models.Workouts.Delete(idInt)
+	if !app.checkWorkoutVersion(w, r, input.Version, workout) {
+		return
+	}
+
+	if input.Date != nil {
+		workout.Date = *input.Date
+	}
+
+	if input.Notes != nil {
+		workout.Notes = *input.Notes
+	}
+
+	v := validator.New()
+
+	if data.ValidateWorkout(v, workout.Date, workout.Notes); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Workouts.Update(workout)
 	if err != nil {
 		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	workout.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"workout": workout}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createWorkoutDetailHandler(w http.ResponseWriter, r *http.Request) {
+
+	workout, ok := app.readWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		ExerciseID      int64    `json:"exercise_id"`
+		Set             int      `json:"set"`
+		Repetitions     int      `json:"repetitions"`
+		Weight          float64  `json:"weight"`
+		DurationSeconds *int     `json:"duration_seconds"`
+		DistanceMeters  *float64 `json:"distance_meters"`
+		HeartRate       *int     `json:"heart_rate"`
+		Calories        *int     `json:"calories"`
+		RPE             *float64 `json:"rpe"`
+		RIR             *int     `json:"rir"`
+		Tempo           string   `json:"tempo"`
+		RestSeconds     *int     `json:"rest_seconds"`
+		SetType         string   `json:"set_type"`
+		Notes           string   `json:"notes"`
+		Unit            string   `json:"unit"`
+		Version         *int     `json:"version"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if !app.checkWorkoutVersion(w, r, input.Version, workout) {
+		return
+	}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+
+	if input.Unit == "" {
+		input.Unit = weightUnit
+	}
+
+	detail := &data.WorkoutDetail{
+		ExerciseID:      input.ExerciseID,
+		Set:             input.Set,
+		Repetitions:     input.Repetitions,
+		Weight:          data.ToKilograms(input.Weight, input.Unit),
+		DurationSeconds: input.DurationSeconds,
+		DistanceMeters:  input.DistanceMeters,
+		HeartRate:       input.HeartRate,
+		Calories:        input.Calories,
+		RPE:             input.RPE,
+		RIR:             input.RIR,
+		Tempo:           input.Tempo,
+		RestSeconds:     input.RestSeconds,
+		SetType:         input.SetType,
+		Notes:           input.Notes,
+	}
+
+	v := validator.New()
+
+	data.ValidateWeightUnit(v, input.Unit)
+
+	err = app.validateWorkoutDetails(v, workout.MemberID, []*data.WorkoutDetail{detail}, false)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Workouts.InsertDetail(workout, detail)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		case errors.Is(err, data.ErrInvalidExercise):
+			app.failedValidationResponse(w, r, map[string]string{"exercise_id": "must reference an existing exercise available to the member"})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	detail.Weight = data.FromKilograms(detail.Weight, weightUnit)
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"detail": detail, "weight_unit": weightUnit, "workout_version": workout.Version}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateWorkoutDetailHandler(w http.ResponseWriter, r *http.Request) {
+
+	workout, ok := app.readWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	detailID, err := app.readNamedIDParam(r, "detail_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var existing *data.WorkoutDetailResponse
+	for _, d := range workout.Details {
+		if d.ID == detailID {
+			existing = d
+			break
+		}
+	}
+
+	if existing == nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		ExerciseID      *int64   `json:"exercise_id"`
+		Set             *int     `json:"set"`
+		Repetitions     *int     `json:"repetitions"`
+		Weight          *float64 `json:"weight"`
+		DurationSeconds *int     `json:"duration_seconds"`
+		DistanceMeters  *float64 `json:"distance_meters"`
+		HeartRate       *int     `json:"heart_rate"`
+		Calories        *int     `json:"calories"`
+		RPE             *float64 `json:"rpe"`
+		RIR             *int     `json:"rir"`
+		Tempo           *string  `json:"tempo"`
+		RestSeconds     *int     `json:"rest_seconds"`
+		SetType         *string  `json:"set_type"`
+		Notes           *string  `json:"notes"`
+		Planned         *bool    `json:"planned"`
+		Unit            string   `json:"unit"`
+		Version         *int     `json:"version"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if !app.checkWorkoutVersion(w, r, input.Version, workout) {
+		return
+	}
+
+	weightUnit := data.WeightUnit(app.callerUnits(r))
+
+	if input.Unit == "" {
+		input.Unit = weightUnit
+	}
+
+	detail := existing.ToDetail()
+
+	if input.ExerciseID != nil {
+		detail.ExerciseID = *input.ExerciseID
+	}
+
+	if input.Set != nil {
+		detail.Set = *input.Set
+	}
+
+	if input.Repetitions != nil {
+		detail.Repetitions = *input.Repetitions
+	}
+
+	if input.Weight != nil {
+		detail.Weight = data.ToKilograms(*input.Weight, input.Unit)
+	}
+
+	if input.DurationSeconds != nil {
+		detail.DurationSeconds = input.DurationSeconds
+	}
+
+	if input.DistanceMeters != nil {
+		detail.DistanceMeters = input.DistanceMeters
+	}
+
+	if input.HeartRate != nil {
+		detail.HeartRate = input.HeartRate
+	}
+
+	if input.Calories != nil {
+		detail.Calories = input.Calories
+	}
+
+	if input.RPE != nil {
+		detail.RPE = input.RPE
+	}
+
+	if input.RIR != nil {
+		detail.RIR = input.RIR
+	}
+
+	if input.Tempo != nil {
+		detail.Tempo = *input.Tempo
+	}
+
+	if input.RestSeconds != nil {
+		detail.RestSeconds = input.RestSeconds
+	}
+
+	if input.SetType != nil {
+		detail.SetType = *input.SetType
+	}
+
+	if input.Notes != nil {
+		detail.Notes = *input.Notes
+	}
+
+	detail.Planned = false
+	if input.Planned != nil {
+		detail.Planned = *input.Planned
+	}
+
+	v := validator.New()
+
+	data.ValidateWeightUnit(v, input.Unit)
+
+	err = app.validateWorkoutDetails(v, workout.MemberID, []*data.WorkoutDetail{detail}, false)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Workouts.UpdateDetail(workout, detail)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		case errors.Is(err, data.ErrInvalidExercise):
+			app.failedValidationResponse(w, r, map[string]string{"exercise_id": "must reference an existing exercise available to the member"})
 		case errors.Is(err, data.ErrRecordNotFound):
 			app.notFoundResponse(w, r)
 		default:
@@ -82,8 +488,208 @@ func (app *application) deleteWorkoutHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"message": "workout sucessfully deleted"}, nil)
+	detail.Weight = data.FromKilograms(detail.Weight, weightUnit)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"detail": detail, "weight_unit": weightUnit, "workout_version": workout.Version}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteWorkoutDetailHandler(w http.ResponseWriter, r *http.Request) {
+
+	workout, ok := app.readWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	if !app.checkWorkoutVersion(w, r, nil, workout) {
+		return
+	}
+
+	detailID, err := app.readNamedIDParam(r, "detail_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Workouts.DeleteDetail(workout, detailID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"message": "workout detail sucessfully deleted", "workout_version": workout.Version}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) reorderWorkoutDetailsHandler(w http.ResponseWriter, r *http.Request) {
+
+	workout, ok := app.readWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		DetailIDs []int64 `json:"detail_ids"`
+		Version   *int    `json:"version"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if !app.checkWorkoutVersion(w, r, input.Version, workout) {
+		return
+	}
+
+	currentIDs := make(map[int64]bool)
+	for _, d := range workout.Details {
+		currentIDs[d.ID] = true
+	}
+
+	allPresent := true
+	for _, id := range input.DetailIDs {
+		if !currentIDs[id] {
+			allPresent = false
+			break
+		}
+	}
+
+	v := validator.New()
+
+	v.Check(len(input.DetailIDs) == len(workout.Details), "detail_ids", "must list every detail of the workout")
+	v.Check(validator.Unique(input.DetailIDs), "detail_ids", "must not contain duplicate values")
+	v.Check(allPresent, "detail_ids", "must only contain details of the workout")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.Workouts.ReorderDetails(workout, input.DetailIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	workout, err = app.models.Workouts.Get(workout.ID, workout.MemberID)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	workout.ConvertWeights(data.WeightUnit(app.callerUnits(r)))
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"workout": workout}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readWorkout(w http.ResponseWriter, r *http.Request) (*data.WorkoutResponse, bool) {
+	memberID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
 	}
+
+	id, err := app.readNamedIDParam(r, "workout_id")
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	workout, err := app.models.Workouts.Get(id, memberID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return workout, true
+}
+
+func (app *application) checkWorkoutVersion(w http.ResponseWriter, r *http.Request, version *int, workout *data.WorkoutResponse) bool {
+	if version == nil {
+		header := r.Header.Get("X-Expected-Version")
+		if header == "" {
+			return true
+		}
+
+		expected, err := strconv.Atoi(header)
+		if err != nil || expected < 1 {
+			app.badRequestResponse(w, r, errors.New("X-Expected-Version header must be a positive integer"))
+			return false
+		}
+
+		version = &expected
+	}
+
+	if *version != workout.Version {
+		app.editConflictResponse(w, r)
+		return false
+	}
+
+	return true
+}
+
+func (app *application) validateWorkoutDetails(v *validator.Validator, memberID int64, details []*data.WorkoutDetail, indexed bool) error {
+	exerciseIDs := []int64{}
+	for _, detail := range details {
+		if detail != nil {
+			exerciseIDs = append(exerciseIDs, detail.ExerciseID)
+		}
+	}
+
+	measurementTypes, err := app.models.Exercises.GetMeasurementTypes(exerciseIDs, memberID)
+	if err != nil {
+		return err
+	}
+
+	for i, detail := range details {
+		prefix := ""
+		if indexed {
+			prefix = fmt.Sprintf("details[%d].", i)
+		}
+
+		if detail == nil {
+			v.AddError(strings.TrimSuffix(prefix, "."), "must be provided")
+			continue
+		}
+
+		measurementType, ok := measurementTypes[detail.ExerciseID]
+		if !ok {
+			v.AddError(prefix+"exercise_id", "must reference an existing exercise available to the member")
+			continue
+		}
+
+		if detail.SetType == "" {
+			detail.SetType = data.SetTypeWorking
+		}
+
+		data.ValidateWorkoutDetail(v, prefix, detail, measurementType)
+	}
+
+	return nil
 }
@@ -3,10 +3,10 @@ module workout-tracker-go.ilijakrilovic.com
 go 1.22.0
 
 require (
-	github.com/joho/godotenv v1.5.1 // indirect
-	github.com/julienschmidt/httprouter v1.3.0 // indirect
-	github.com/lib/pq v1.10.0 // indirect
-	github.com/pascaldekloe/jwt v1.10.0 // indirect
-	golang.org/x/crypto v0.29.0 // indirect
-	golang.org/x/time v0.8.0 // indirect
+	github.com/joho/godotenv v1.5.1
+	github.com/julienschmidt/httprouter v1.3.0
+	github.com/lib/pq v1.10.0
+	github.com/pascaldekloe/jwt v1.10.0
+	golang.org/x/crypto v0.29.0
+	golang.org/x/time v0.8.0
 )
@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"workout-tracker-go.ilijakrilovic.com/internal/validator"
 )
 
@@ -19,12 +21,86 @@ var allowedCategories = map[string]bool{
 	"cardio":    true,
 }
 
+var allowedEquipment = map[string]bool{
+	"barbell":    true,
+	"dumbbell":   true,
+	"kettlebell": true,
+	"machine":    true,
+	"cable":      true,
+	"band":       true,
+	"bodyweight": true,
+	"other":      true,
+}
+
+var allowedMuscles = map[string]bool{
+	"chest":      true,
+	"shoulders":  true,
+	"triceps":    true,
+	"biceps":     true,
+	"forearms":   true,
+	"lats":       true,
+	"traps":      true,
+	"upper_back": true,
+	"lower_back": true,
+	"abs":        true,
+	"obliques":   true,
+	"glutes":     true,
+	"quadriceps": true,
+	"hamstrings": true,
+	"adductors":  true,
+	"abductors":  true,
+	"calves":     true,
+	"full_body":  true,
+}
+
+var allowedMechanics = map[string]bool{
+	"compound":  true,
+	"isolation": true,
+}
+
+const (
+	MeasurementWeightReps     = "weight_reps"
+	MeasurementBodyweightReps = "bodyweight_reps"
+	MeasurementTime           = "time"
+	MeasurementDistance       = "distance"
+)
+
+var allowedMeasurementTypes = map[string]bool{
+	MeasurementWeightReps:     true,
+	MeasurementBodyweightReps: true,
+	MeasurementTime:           true,
+	MeasurementDistance:       true,
+}
+
 type Exercise struct {
-	ID          int64  `json:"-"`
-	Name        string `json:"name"`
-	Category    string `json:"category"`
-	Description string `json:"description"`
-	Version     int    `json:"-"`
+	ID               int64    `json:"id"`
+	Name             string   `json:"name"`
+	Category         string   `json:"category"`
+	Description      string   `json:"description"`
+	Equipment        string   `json:"equipment,omitempty"`
+	PrimaryMuscles   []string `json:"primary_muscles,omitempty"`
+	SecondaryMuscles []string `json:"secondary_muscles,omitempty"`
+	Mechanic         string   `json:"mechanic,omitempty"`
+	MeasurementType  string   `json:"measurement_type,omitempty"`
+	OwnerMemberID    *int64   `json:"owner_member_id,omitempty"`
+	Version          int      `json:"-"`
+}
+
+type ExerciseFilters struct {
+	Search           string
+	Categories       []string
+	Equipment        []string
+	Muscles          []string
+	Mechanic         string
+	MeasurementTypes []string
+}
+
+func (e *Exercise) IsPrivate() bool {
+	return e.OwnerMemberID != nil
+}
+
+func (e *Exercise) IsOwnedBy(memberID int64) bool {
+	return e.OwnerMemberID != nil && *e.OwnerMemberID == memberID
 }
 
 func ValidateCategory(v *validator.Validator, category string) {
@@ -32,6 +108,14 @@ func ValidateCategory(v *validator.Validator, category string) {
 	v.Check(allowedCategories[category], "category", "invalid category")
 }
 
+func ValidateCategories(v *validator.Validator, categories []string) {
+	v.Check(validator.Unique(categories), "category", "must not contain duplicate values")
+
+	for _, category := range categories {
+		v.Check(allowedCategories[category], "category", "invalid category")
+	}
+}
+
 func ValidateExercise(v *validator.Validator, exercise *Exercise) {
 	v.Check(exercise.Name != "", "name", "must be provided")
 	v.Check(len(exercise.Name) <= 50, "name", "must not be more than 50 butes long")
@@ -39,44 +123,176 @@ func ValidateExercise(v *validator.Validator, exercise *Exercise) {
 	ValidateCategory(v, exercise.Category)
 
 	v.Check(len(exercise.Description) <= 1000, "description", "must not be more than 1000 bytes long")
+
+	v.Check(exercise.Equipment == "" || allowedEquipment[exercise.Equipment], "equipment", "invalid equipment")
+	v.Check(exercise.Mechanic == "" || allowedMechanics[exercise.Mechanic], "mechanic", "must be either compound or isolation")
+
+	v.Check(exercise.MeasurementType != "", "measurement_type", "must be provided")
+	v.Check(allowedMeasurementTypes[exercise.MeasurementType], "measurement_type", "invalid measurement type")
+
+	validateMuscles(v, "primary_muscles", exercise.PrimaryMuscles)
+	validateMuscles(v, "secondary_muscles", exercise.SecondaryMuscles)
+
+	for _, muscle := range exercise.SecondaryMuscles {
+		v.Check(!validator.PermittedValue(muscle, exercise.PrimaryMuscles...), "secondary_muscles", "must not repeat a primary muscle")
+	}
+}
+
+func ValidateExerciseFilters(v *validator.Validator, f ExerciseFilters) {
+	v.Check(len(f.Search) <= 200, "q", "must not be more than 200 bytes long")
+
+	ValidateCategories(v, f.Categories)
+
+	for _, equipment := range f.Equipment {
+		v.Check(allowedEquipment[equipment], "equipment", "invalid equipment")
+	}
+
+	for _, muscle := range f.Muscles {
+		v.Check(allowedMuscles[muscle], "muscle", "invalid muscle")
+	}
+
+	for _, measurementType := range f.MeasurementTypes {
+		v.Check(allowedMeasurementTypes[measurementType], "measurement_type", "invalid measurement type")
+	}
+
+	v.Check(f.Mechanic == "" || allowedMechanics[f.Mechanic], "mechanic", "must be either compound or isolation")
+}
+
+func validateMuscles(v *validator.Validator, key string, muscles []string) {
+	v.Check(len(muscles) <= 10, key, "must not contain more than 10 muscles")
+	v.Check(validator.Unique(muscles), key, "must not contain duplicate values")
+
+	for _, muscle := range muscles {
+		v.Check(allowedMuscles[muscle], key, "invalid muscle")
+	}
 }
 
 type ExerciseModel struct {
 	DB *sql.DB
 }
 
-func (e ExerciseModel) Insert(exercise *Exercise) error {
-	query := `
-		INSERT INTO exercises (name, category, description)
-		VALUES ($1, $2, $3)
+const insertExerciseQuery = `
+		INSERT INTO exercises (name, category, description, equipment, primary_muscles, secondary_muscles, mechanic, measurement_type, owner_member_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, version
 		`
 
-	args := []interface{}{exercise.Name, exercise.Category, exercise.Description}
+func (e *Exercise) insertValues() []interface{} {
+	return []interface{}{
+		e.Name,
+		e.Category,
+		e.Description,
+		e.Equipment,
+		muscleArray(e.PrimaryMuscles),
+		muscleArray(e.SecondaryMuscles),
+		e.Mechanic,
+		e.MeasurementType,
+		e.OwnerMemberID,
+	}
+}
 
+func muscleArray(muscles []string) interface{} {
+	if muscles == nil {
+		muscles = []string{}
+	}
+	return pq.Array(muscles)
+}
+
+func (e ExerciseModel) Insert(exercise *Exercise) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	return e.DB.QueryRowContext(ctx, query, args...).Scan(&exercise.ID, &exercise.Version)
+	return e.DB.QueryRowContext(ctx, insertExerciseQuery, exercise.insertValues()...).Scan(&exercise.ID, &exercise.Version)
 }
 
-func (e ExerciseModel) GetByCategory(category string) ([]*Exercise, error) {
-	query := `
-		SELECT id, name, category, description, version
+func (e ExerciseModel) GetAll(exerciseFilters ExerciseFilters, memberID int64, filters Filters) ([]*Exercise, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, name, category, description, equipment, primary_muscles, secondary_muscles, mechanic, measurement_type, owner_member_id, version
 		FROM exercises
-		WHERE category = $1
-		ORDER BY id`
-
-	args := []interface{}{category}
+		WHERE (to_tsvector('english', name || ' ' || coalesce(description, '')) @@ plainto_tsquery('english', $1) OR $1 = '')
+		AND (category = ANY($2) OR cardinality($2::text[]) = 0)
+		AND (equipment = ANY($3) OR cardinality($3::text[]) = 0)
+		AND (primary_muscles && $4 OR secondary_muscles && $4 OR cardinality($4::text[]) = 0)
+		AND (mechanic = $5 OR $5 = '')
+		AND (measurement_type = ANY($6) OR cardinality($6::text[]) = 0)
+		AND (owner_member_id IS NULL OR owner_member_id = $7)
+		ORDER BY %s %s, id ASC
+		LIMIT $8 OFFSET $9`, filters.sortColumn(), filters.sortDirection())
+
+	args := []interface{}{
+		exerciseFilters.Search,
+		pq.Array(exerciseFilters.Categories),
+		pq.Array(exerciseFilters.Equipment),
+		pq.Array(exerciseFilters.Muscles),
+		exerciseFilters.Mechanic,
+		pq.Array(exerciseFilters.MeasurementTypes),
+		memberID,
+		filters.limit(),
+		filters.offset(),
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	rows, err := e.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, Metadata{}, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	exercises := []*Exercise{}
+
+	for rows.Next() {
+		var exercise Exercise
+
+		err := rows.Scan(
+			&totalRecords,
+			&exercise.ID,
+			&exercise.Name,
+			&exercise.Category,
+			&exercise.Description,
+			&exercise.Equipment,
+			pq.Array(&exercise.PrimaryMuscles),
+			pq.Array(&exercise.SecondaryMuscles),
+			&exercise.Mechanic,
+			&exercise.MeasurementType,
+			&exercise.OwnerMemberID,
+			&exercise.Version,
+		)
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		exercises = append(exercises, &exercise)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return exercises, metadata, nil
+}
+
+func (e ExerciseModel) GetVisible(memberID int64) ([]*Exercise, error) {
+	query := `
+		SELECT id, name, category, description, equipment, primary_muscles, secondary_muscles, mechanic, measurement_type, owner_member_id, version
+		FROM exercises
+		WHERE owner_member_id IS NULL OR owner_member_id = $1
+		ORDER BY id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := e.DB.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	exercises := []*Exercise{}
@@ -89,6 +305,12 @@ func (e ExerciseModel) GetByCategory(category string) ([]*Exercise, error) {
 			&exercise.Name,
 			&exercise.Category,
 			&exercise.Description,
+			&exercise.Equipment,
+			pq.Array(&exercise.PrimaryMuscles),
+			pq.Array(&exercise.SecondaryMuscles),
+			&exercise.Mechanic,
+			&exercise.MeasurementType,
+			&exercise.OwnerMemberID,
 			&exercise.Version,
 		)
 
@@ -108,7 +330,7 @@ func (e ExerciseModel) GetByCategory(category string) ([]*Exercise, error) {
 
 func (e ExerciseModel) GetById(id int64) (*Exercise, error) {
 	query := `
-		SELECT id, name, category, description, version
+		SELECT id, name, category, description, equipment, primary_muscles, secondary_muscles, mechanic, measurement_type, owner_member_id, version
 		FROM exercises
 		WHERE id = $1
 	`
@@ -123,6 +345,12 @@ func (e ExerciseModel) GetById(id int64) (*Exercise, error) {
 		&exercise.Name,
 		&exercise.Category,
 		&exercise.Description,
+		&exercise.Equipment,
+		pq.Array(&exercise.PrimaryMuscles),
+		pq.Array(&exercise.SecondaryMuscles),
+		&exercise.Mechanic,
+		&exercise.MeasurementType,
+		&exercise.OwnerMemberID,
 		&exercise.Version,
 	)
 
@@ -138,15 +366,65 @@ func (e ExerciseModel) GetById(id int64) (*Exercise, error) {
 	return &exercise, nil
 }
 
+func (e ExerciseModel) GetMeasurementTypes(ids []int64, memberID int64) (map[int64]string, error) {
+	query := `
+		SELECT id, measurement_type
+		FROM exercises
+		WHERE id = ANY($1)
+		AND (owner_member_id IS NULL OR owner_member_id = $2)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := e.DB.QueryContext(ctx, query, pq.Array(ids), memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	measurementTypes := make(map[int64]string)
+
+	for rows.Next() {
+		var id int64
+		var measurementType string
+
+		err := rows.Scan(&id, &measurementType)
+		if err != nil {
+			return nil, err
+		}
+
+		measurementTypes[id] = measurementType
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return measurementTypes, nil
+}
+
 func (e ExerciseModel) Update(exercise *Exercise) error {
 	query := `
 		UPDATE exercises
-		SET name = $1, category = $2, description = $3, version = version +1
-		WHERE id = $4 AND version = $5
+		SET name = $1, category = $2, description = $3, equipment = $4, primary_muscles = $5, secondary_muscles = $6,
+			mechanic = $7, measurement_type = $8, version = version +1
+		WHERE id = $9 AND version = $10
 		RETURNING version
 		`
 
-	args := []interface{}{exercise.Name, exercise.Category, exercise.Description, exercise.ID, exercise.Version}
+	args := []interface{}{
+		exercise.Name,
+		exercise.Category,
+		exercise.Description,
+		exercise.Equipment,
+		muscleArray(exercise.PrimaryMuscles),
+		muscleArray(exercise.SecondaryMuscles),
+		exercise.Mechanic,
+		exercise.MeasurementType,
+		exercise.ID,
+		exercise.Version,
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -155,7 +433,7 @@ func (e ExerciseModel) Update(exercise *Exercise) error {
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			return ErrRecordNotFound
+			return ErrEditConflict
 		default:
 			return err
 		}
@@ -166,7 +444,7 @@ func (e ExerciseModel) Update(exercise *Exercise) error {
 func (e ExerciseModel) Delete(id int64) error {
 	query := `
 		DELETE FROM exercises
-		WHERE id = $1
+		WHERE id = $1 AND owner_member_id IS NULL
 	`
 	result, err := e.DB.Exec(query, id)
 	if err != nil {
@@ -184,3 +462,25 @@ func (e ExerciseModel) Delete(id int64) error {
 
 	return nil
 }
+
+func (e ExerciseModel) DeletePrivate(id, memberID int64) error {
+	query := `
+		DELETE FROM exercises
+		WHERE id = $1 AND owner_member_id = $2
+	`
+	result, err := e.DB.Exec(query, id, memberID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package data
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const ExerciseMatchThreshold = 0.9
+
+type ImportedWorkout struct {
+	Workout   *Workout
+	Exercises []*Exercise
+}
+
+var equipmentAliases = map[string]string{
+	"barbell":       "barbell",
+	"bb":            "barbell",
+	"ez bar":        "barbell",
+	"dumbbell":      "dumbbell",
+	"dumbbells":     "dumbbell",
+	"db":            "dumbbell",
+	"kettlebell":    "kettlebell",
+	"kb":            "kettlebell",
+	"machine":       "machine",
+	"smith machine": "machine",
+	"assisted":      "machine",
+	"cable":         "cable",
+	"band":          "band",
+	"bodyweight":    "bodyweight",
+}
+
+type exerciseName struct {
+	tokens    []string
+	equipment string
+}
+
+func parseExerciseName(name string) exerciseName {
+	var parsed exerciseName
+
+	name = strings.ToLower(name)
+
+	for {
+		open := strings.Index(name, "(")
+		if open == -1 {
+			break
+		}
+
+		end := strings.Index(name[open:], ")")
+		if end == -1 {
+			name = name[:open] + " " + name[open+1:]
+			break
+		}
+
+		inner := strings.TrimSpace(name[open+1 : open+end])
+		if equipment, ok := equipmentAliases[inner]; ok {
+			parsed.equipment = equipment
+			inner = ""
+		}
+
+		name = name[:open] + " " + inner + " " + name[open+end+1:]
+	}
+
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		if equipment, ok := equipmentAliases[word]; ok {
+			if parsed.equipment == "" {
+				parsed.equipment = equipment
+			}
+			continue
+		}
+
+		if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			word = strings.TrimSuffix(word, "s")
+		}
+
+		parsed.tokens = append(parsed.tokens, word)
+	}
+
+	return parsed
+}
+
+func MatchExercise(name string, exercises []*Exercise) (*Exercise, float64) {
+	var (
+		best      *Exercise
+		bestScore float64
+	)
+
+	query := parseExerciseName(name)
+
+	for _, exercise := range exercises {
+		var score float64
+
+		if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(exercise.Name)) {
+			score = 1
+		} else {
+			score = exerciseNameSimilarity(query, exercise)
+		}
+
+		if score > bestScore || (score == bestScore && best != nil && exercise.IsPrivate() && !best.IsPrivate()) {
+			best = exercise
+			bestScore = score
+		}
+	}
+
+	if bestScore < ExerciseMatchThreshold {
+		return nil, bestScore
+	}
+
+	return best, bestScore
+}
+
+func exerciseNameSimilarity(query exerciseName, exercise *Exercise) float64 {
+	candidate := parseExerciseName(exercise.Name)
+	if candidate.equipment == "" {
+		candidate.equipment = exercise.Equipment
+	}
+
+	if len(query.tokens) == 0 || len(candidate.tokens) == 0 {
+		return 0
+	}
+
+	score := tokenSimilarity(query.tokens, candidate.tokens)
+
+	joined := strings.Join(query.tokens, "")
+	candidateJoined := strings.Join(candidate.tokens, "")
+
+	if s := editSimilarity(joined, candidateJoined); s > score {
+		score = s
+	}
+
+	if query.equipment != "" && candidate.equipment != "" {
+		if query.equipment == candidate.equipment {
+			score += 0.05
+		} else {
+			score -= 0.2
+		}
+	}
+
+	if score > 1 {
+		score = 1
+	}
+
+	return float64(int(score*100)) / 100
+}
+
+func tokenSimilarity(a, b []string) float64 {
+	set := make(map[string]bool)
+	for _, token := range b {
+		set[token] = true
+	}
+
+	shared := 0
+	for _, token := range a {
+		if set[token] {
+			shared++
+			delete(set, token)
+		}
+	}
+
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
+
+func editSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+
+	if longest == 0 {
+		return 1
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return 1 - float64(previous[len(rb)])/float64(longest)
+}
+
+func (w WorkoutModel) Import(workouts []*ImportedWorkout) error {
+
+	tx, err := w.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	exerciseIDs := []int64{}
+
+	for _, imported := range workouts {
+		for i, exercise := range imported.Exercises {
+			if exercise.ID == 0 {
+				err = tx.QueryRowContext(ctx, insertExerciseQuery, exercise.insertValues()...).Scan(&exercise.ID, &exercise.Version)
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+
+			imported.Workout.Details[i].ExerciseID = exercise.ID
+			exerciseIDs = append(exerciseIDs, exercise.ID)
+		}
+
+		err = insertWorkoutRows(ctx, tx, imported.Workout)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if len(exerciseIDs) > 0 {
+		_, err = recomputePersonalRecords(ctx, tx, workouts[0].Workout.MemberID, exerciseIDs)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,297 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+const DefaultMovingAverageWindow = 7
+
+type BodyMeasurement struct {
+	ID                int64     `json:"id"`
+	MemberID          int64     `json:"member_id"`
+	Date              time.Time `json:"date"`
+	Weight            *float64  `json:"weight,omitempty"`
+	BodyFatPercentage *float64  `json:"body_fat_percentage,omitempty"`
+	Neck              *float64  `json:"neck,omitempty"`
+	Chest             *float64  `json:"chest,omitempty"`
+	Waist             *float64  `json:"waist,omitempty"`
+	Hips              *float64  `json:"hips,omitempty"`
+	Arms              *float64  `json:"arms,omitempty"`
+	Thighs            *float64  `json:"thighs,omitempty"`
+	Calves            *float64  `json:"calves,omitempty"`
+	Notes             string    `json:"notes,omitempty"`
+	WeightAverage     *float64  `json:"weight_moving_average,omitempty"`
+	BodyFatAverage    *float64  `json:"body_fat_moving_average,omitempty"`
+	WeightUnit        string    `json:"weight_unit,omitempty"`
+	LengthUnit        string    `json:"length_unit,omitempty"`
+}
+
+func (m *BodyMeasurement) lengths() []**float64 {
+	return []**float64{&m.Neck, &m.Chest, &m.Waist, &m.Hips, &m.Arms, &m.Thighs, &m.Calves}
+}
+
+func (m *BodyMeasurement) FromUnits(units string) {
+	convertOptional(&m.Weight, func(v float64) float64 { return ToKilograms(v, WeightUnit(units)) })
+
+	for _, length := range m.lengths() {
+		convertOptional(length, func(v float64) float64 { return ToCentimeters(v, HeightUnit(units)) })
+	}
+}
+
+func (m *BodyMeasurement) ConvertUnits(units string) {
+	m.WeightUnit = WeightUnit(units)
+	m.LengthUnit = HeightUnit(units)
+
+	convertOptional(&m.Weight, func(v float64) float64 { return FromKilograms(v, m.WeightUnit) })
+	convertOptional(&m.WeightAverage, func(v float64) float64 { return FromKilograms(v, m.WeightUnit) })
+
+	for _, length := range m.lengths() {
+		convertOptional(length, func(v float64) float64 { return FromCentimetersPrecise(v, m.LengthUnit) })
+	}
+}
+
+func convertOptional(value **float64, convert func(float64) float64) {
+	if *value == nil {
+		return
+	}
+
+	converted := convert(**value)
+	*value = &converted
+}
+
+func ValidateBodyMeasurement(v *validator.Validator, m *BodyMeasurement) {
+	v.Check(!m.Date.IsZero(), "date", "must be provided")
+	v.Check(!m.Date.After(time.Now().UTC()), "date", "must not be in the future")
+	v.Check(len(m.Notes) <= 500, "notes", "must not be more than 500 bytes long")
+
+	provided := m.Weight != nil || m.BodyFatPercentage != nil
+
+	if m.Weight != nil {
+		v.Check(*m.Weight > 0 && *m.Weight <= 1000, "weight", "must be between 0 and 1000 kg")
+	}
+
+	if m.BodyFatPercentage != nil {
+		v.Check(*m.BodyFatPercentage > 0 && *m.BodyFatPercentage < 100, "body_fat_percentage", "must be between 0 and 100")
+	}
+
+	names := []string{"neck", "chest", "waist", "hips", "arms", "thighs", "calves"}
+
+	for i, length := range m.lengths() {
+		if *length != nil {
+			provided = true
+			v.Check(**length > 0 && **length <= 500, names[i], "must be between 0 and 500 cm")
+		}
+	}
+
+	v.Check(provided, "measurements", "must contain at least one measurement")
+}
+
+func ValidateMovingAverageWindow(v *validator.Validator, window int) {
+	v.Check(window >= 1 && window <= 90, "window", "must be between 1 and 90 days")
+}
+
+type BodyMeasurementModel struct {
+	DB *sql.DB
+}
+
+func (m BodyMeasurementModel) Insert(measurement *BodyMeasurement) error {
+	query := `
+		INSERT INTO body_measurements (member_id, date, weight, body_fat_percentage, neck, chest, waist, hips, arms, thighs, calves, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (member_id, date) DO UPDATE
+		SET weight = COALESCE(EXCLUDED.weight, body_measurements.weight),
+			body_fat_percentage = COALESCE(EXCLUDED.body_fat_percentage, body_measurements.body_fat_percentage),
+			neck = COALESCE(EXCLUDED.neck, body_measurements.neck),
+			chest = COALESCE(EXCLUDED.chest, body_measurements.chest),
+			waist = COALESCE(EXCLUDED.waist, body_measurements.waist),
+			hips = COALESCE(EXCLUDED.hips, body_measurements.hips),
+			arms = COALESCE(EXCLUDED.arms, body_measurements.arms),
+			thighs = COALESCE(EXCLUDED.thighs, body_measurements.thighs),
+			calves = COALESCE(EXCLUDED.calves, body_measurements.calves),
+			notes = CASE WHEN EXCLUDED.notes = '' THEN body_measurements.notes ELSE EXCLUDED.notes END
+		RETURNING id, weight, body_fat_percentage, neck, chest, waist, hips, arms, thighs, calves, notes
+	`
+
+	args := []interface{}{
+		measurement.MemberID,
+		measurement.Date,
+		measurement.Weight,
+		measurement.BodyFatPercentage,
+		measurement.Neck,
+		measurement.Chest,
+		measurement.Waist,
+		measurement.Hips,
+		measurement.Arms,
+		measurement.Thighs,
+		measurement.Calves,
+		measurement.Notes,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(
+		&measurement.ID,
+		&measurement.Weight,
+		&measurement.BodyFatPercentage,
+		&measurement.Neck,
+		&measurement.Chest,
+		&measurement.Waist,
+		&measurement.Hips,
+		&measurement.Arms,
+		&measurement.Thighs,
+		&measurement.Calves,
+		&measurement.Notes,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = syncMemberWeight(ctx, tx, measurement.MemberID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m BodyMeasurementModel) GetAll(memberID int64, workoutFilters WorkoutFilters, window int, filters Filters) ([]*BodyMeasurement, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, member_id, date, weight, body_fat_percentage, neck, chest, waist, hips, arms, thighs, calves, notes,
+			weight_average, body_fat_average
+		FROM (
+			SELECT *,
+				AVG(weight) OVER moving AS weight_average,
+				ROUND((AVG(body_fat_percentage) OVER moving)::numeric, 1)::float AS body_fat_average
+			FROM body_measurements
+			WHERE member_id = $1
+			WINDOW moving AS (ORDER BY date RANGE BETWEEN make_interval(days => $4::int) PRECEDING AND CURRENT ROW)
+		) measurements
+		WHERE ($2::timestamp IS NULL OR date >= $2)
+		AND ($3::timestamp IS NULL OR date < $3)
+		ORDER BY %s %s, id ASC
+		LIMIT $5 OFFSET $6`, filters.sortColumn(), filters.sortDirection())
+
+	args := []interface{}{
+		memberID,
+		workoutFilters.From,
+		workoutFilters.to(),
+		window - 1,
+		filters.limit(),
+		filters.offset(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	measurements := []*BodyMeasurement{}
+
+	for rows.Next() {
+		var measurement BodyMeasurement
+
+		err := rows.Scan(
+			&totalRecords,
+			&measurement.ID,
+			&measurement.MemberID,
+			&measurement.Date,
+			&measurement.Weight,
+			&measurement.BodyFatPercentage,
+			&measurement.Neck,
+			&measurement.Chest,
+			&measurement.Waist,
+			&measurement.Hips,
+			&measurement.Arms,
+			&measurement.Thighs,
+			&measurement.Calves,
+			&measurement.Notes,
+			&measurement.WeightAverage,
+			&measurement.BodyFatAverage,
+		)
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		measurements = append(measurements, &measurement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return measurements, metadata, nil
+}
+
+func (m BodyMeasurementModel) Delete(id, memberID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM body_measurements WHERE id = $1 AND member_id = $2`, id, memberID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if rowsAffected == 0 {
+		tx.Rollback()
+		return ErrRecordNotFound
+	}
+
+	err = syncMemberWeight(ctx, tx, memberID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func syncMemberWeight(ctx context.Context, tx *sql.Tx, memberID int64) error {
+	query := `
+		UPDATE members m
+		SET weight = latest.weight, version = m.version + 1
+		FROM (
+			SELECT weight
+			FROM body_measurements
+			WHERE member_id = $1 AND weight IS NOT NULL
+			ORDER BY date DESC
+			LIMIT 1
+		) latest
+		WHERE m.id = $1 AND m.weight IS DISTINCT FROM latest.weight
+	`
+
+	_, err := tx.ExecContext(ctx, query, memberID)
+	return err
+}
@@ -5,6 +5,8 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"errors"
+	"fmt"
+	"math"
 	"time"
 	"unicode"
 
@@ -15,15 +17,62 @@ import (
 var AnonymousUser = &Member{}
 
 type Member struct {
-	ID        int64     `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	Password  password  `json:"-"`
-	Activated bool      `json:"activated"`
-	Height    int64     `json:"height"`
-	Weight    int64     `json:"weight"`
-	CreatedAt time.Time `json:"created_at"`
-	Version   int       `json:"-"`
+	ID             int64     `json:"id"`
+	Email          string    `json:"email"`
+	Name           string    `json:"name"`
+	Password       password  `json:"-"`
+	Activated      bool      `json:"activated"`
+	Height         int64     `json:"height"`
+	Weight         float64   `json:"weight"`
+	PreferredUnits string    `json:"preferred_units"`
+	PendingEmail   *string   `json:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+	Version        int       `json:"-"`
+}
+
+type MemberResponse struct {
+	ID             int64     `json:"id"`
+	Email          string    `json:"email"`
+	Name           string    `json:"name"`
+	Activated      bool      `json:"activated"`
+	Height         float64   `json:"height"`
+	HeightUnit     string    `json:"height_unit"`
+	HeightFeet     string    `json:"height_ft_in,omitempty"`
+	Weight         float64   `json:"weight"`
+	WeightUnit     string    `json:"weight_unit"`
+	PreferredUnits string    `json:"preferred_units"`
+	PendingEmail   string    `json:"pending_email,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (m *Member) SetHeightAndWeight(height, weight float64, units string) {
+	m.Height = int64(math.Round(ToCentimeters(height, HeightUnit(units))))
+	m.Weight = math.Round(ToKilograms(weight, WeightUnit(units))*100) / 100
+}
+
+func (m *Member) Response(units string) *MemberResponse {
+	response := &MemberResponse{
+		ID:             m.ID,
+		Email:          m.Email,
+		Name:           m.Name,
+		Activated:      m.Activated,
+		Height:         FromCentimeters(float64(m.Height), HeightUnit(units)),
+		HeightUnit:     HeightUnit(units),
+		Weight:         FromKilograms(m.Weight, WeightUnit(units)),
+		WeightUnit:     WeightUnit(units),
+		PreferredUnits: m.PreferredUnits,
+		CreatedAt:      m.CreatedAt,
+	}
+
+	if m.PendingEmail != nil {
+		response.PendingEmail = *m.PendingEmail
+	}
+
+	if units == UnitsImperial && m.Height > 0 {
+		response.HeightFeet = FeetAndInches(float64(m.Height))
+	}
+
+	return response
 }
 
 type password struct {
@@ -102,6 +151,10 @@ func ValidateMember(v *validator.Validator, member *Member) {
 	v.Check(len(member.Name) <= 100, "name", "must not be more than 100 bytes long")
 
 	ValidateEmail(v, member.Email)
+	ValidateUnits(v, member.PreferredUnits)
+
+	v.Check(member.Height >= 0, "height", "must not be negative")
+	v.Check(member.Weight >= 0, "weight", "must not be negative")
 
 	if member.Password.plaintext != nil {
 		ValidatePassword(v, *member.Password.plaintext)
@@ -115,12 +168,12 @@ type MemberModel struct {
 
 func (m MemberModel) Insert(member *Member) error {
 	query := `
-		INSERT INTO members (email, name, password_hash, activated, height, weight)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO members (email, name, password_hash, activated, height, weight, preferred_units)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id, created_at, version
 	`
 
-	args := []interface{}{member.Email, member.Name, member.Password.hash, member.Activated, member.Height, member.Weight}
+	args := []interface{}{member.Email, member.Name, member.Password.hash, member.Activated, member.Height, member.Weight, member.PreferredUnits}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -129,7 +182,7 @@ func (m MemberModel) Insert(member *Member) error {
 	if err != nil {
 		switch {
 		case err.Error() == `pq: duplicate key value violates unique constraint "members_email_key"`:
-			return errors.New("duplicate email")
+			return ErrDuplicateEmail
 		default:
 			return err
 		}
@@ -140,7 +193,7 @@ func (m MemberModel) Insert(member *Member) error {
 
 func (m MemberModel) GetByEmail(email string) (*Member, error) {
 	query := `
-		SELECT id, email, name, password_hash, activated, height, weight, created_at, version
+		SELECT id, email, name, password_hash, activated, height, weight, preferred_units, pending_email, created_at, version
 		FROM members
 		WHERE email = $1
 	`
@@ -158,6 +211,8 @@ func (m MemberModel) GetByEmail(email string) (*Member, error) {
 		&member.Activated,
 		&member.Height,
 		&member.Weight,
+		&member.PreferredUnits,
+		&member.PendingEmail,
 		&member.CreatedAt,
 		&member.Version,
 	)
@@ -174,9 +229,66 @@ func (m MemberModel) GetByEmail(email string) (*Member, error) {
 	return &member, nil
 }
 
+func (m MemberModel) GetAll(name, email string, filters Filters) ([]*Member, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, email, name, password_hash, activated, height, weight, preferred_units, pending_email, created_at, version
+		FROM members
+		WHERE (name ILIKE ('%%' || $1 || '%%') ESCAPE '\' OR $1 = '')
+		AND (email = $2 OR $2 = '')
+		ORDER BY %s %s, id ASC
+		LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	args := []interface{}{escapeLike(name), email, filters.limit(), filters.offset()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	members := []*Member{}
+
+	for rows.Next() {
+		var member Member
+
+		err := rows.Scan(
+			&totalRecords,
+			&member.ID,
+			&member.Email,
+			&member.Name,
+			&member.Password.hash,
+			&member.Activated,
+			&member.Height,
+			&member.Weight,
+			&member.PreferredUnits,
+			&member.PendingEmail,
+			&member.CreatedAt,
+			&member.Version,
+		)
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		members = append(members, &member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return members, metadata, nil
+}
+
 func (m MemberModel) GetById(id int64) (*Member, error) {
 	query := `
-	SELECT id, email, name, password_hash, activated, height, weight, created_at, version
+	SELECT id, email, name, password_hash, activated, height, weight, preferred_units, pending_email, created_at, version
 	FROM members
 	WHERE id = $1
 	`
@@ -194,6 +306,8 @@ func (m MemberModel) GetById(id int64) (*Member, error) {
 		&member.Activated,
 		&member.Height,
 		&member.Weight,
+		&member.PreferredUnits,
+		&member.PendingEmail,
 		&member.CreatedAt,
 		&member.Version,
 	)
@@ -213,8 +327,8 @@ func (m MemberModel) GetById(id int64) (*Member, error) {
 func (m MemberModel) Update(member *Member) error {
 	query := `
 		UPDATE members
-		SET email = $1, name = $2, password_hash = $3, activated = $4, height = $5, weight = $6, version = version + 1
-		WHERE id = $7 AND version = $8
+		SET email = $1, name = $2, password_hash = $3, activated = $4, height = $5, weight = $6, preferred_units = $7, pending_email = $8, version = version + 1
+		WHERE id = $9 AND version = $10
 		RETURNING version
 	`
 
@@ -225,6 +339,8 @@ func (m MemberModel) Update(member *Member) error {
 		member.Activated,
 		member.Height,
 		member.Weight,
+		member.PreferredUnits,
+		member.PendingEmail,
 		member.ID,
 		member.Version,
 	}
@@ -235,8 +351,10 @@ func (m MemberModel) Update(member *Member) error {
 	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&member.Version)
 	if err != nil {
 		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "members_email_key"`:
+			return ErrDuplicateEmail
 		case errors.Is(err, sql.ErrNoRows):
-			return ErrRecordNotFound
+			return ErrEditConflict
 		default:
 			return err
 		}
@@ -272,7 +390,7 @@ func (m MemberModel) GetForToken(tokenScope, tokenPlain string) (*Member, error)
 	tokenHash := sha256.Sum256([]byte(tokenPlain))
 
 	query := `
-		SELECT m.id, m.email, m.name, m.password_hash, m.activated, m.height, m.weight, m.created_at, m.version
+		SELECT m.id, m.email, m.name, m.password_hash, m.activated, m.height, m.weight, m.preferred_units, m.pending_email, m.created_at, m.version
 		FROM members m
 		INNER JOIN tokens
 		ON m.id = tokens.member_id
@@ -296,6 +414,8 @@ func (m MemberModel) GetForToken(tokenScope, tokenPlain string) (*Member, error)
 		&member.Activated,
 		&member.Height,
 		&member.Weight,
+		&member.PreferredUnits,
+		&member.PendingEmail,
 		&member.CreatedAt,
 		&member.Version,
 	)
@@ -6,21 +6,41 @@ import (
 )
 
 var (
-	ErrRecordNotFound = errors.New("record not found")
+	ErrRecordNotFound  = errors.New("record not found")
+	ErrInvalidExercise = errors.New("invalid exercise")
+	ErrEditConflict    = errors.New("edit conflict")
+	ErrInvalidRoutine  = errors.New("invalid routine")
+	ErrDuplicateEmail  = errors.New("duplicate email")
 )
 
 type Models struct {
-	Members   MemberModel
-	Exercises ExerciseModel
-	Workouts  WorkoutModel
-	Tokens    TokenModel
+	Members      MemberModel
+	Exercises    ExerciseModel
+	Workouts     WorkoutModel
+	Tokens       TokenModel
+	Permissions  PermissionModel
+	Records      PersonalRecordModel
+	Stats        StatsModel
+	Routines     RoutineModel
+	Programs     ProgramModel
+	Schedule     ScheduleModel
+	Measurements BodyMeasurementModel
+	Sessions     SessionModel
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Members:   MemberModel{DB: db},
-		Exercises: ExerciseModel{DB: db},
-		Workouts:  WorkoutModel{DB: db},
-		Tokens:    TokenModel{DB: db},
+		Members:      MemberModel{DB: db},
+		Exercises:    ExerciseModel{DB: db},
+		Workouts:     WorkoutModel{DB: db},
+		Tokens:       TokenModel{DB: db},
+		Permissions:  PermissionModel{DB: db},
+		Records:      PersonalRecordModel{DB: db},
+		Stats:        StatsModel{DB: db},
+		Routines:     RoutineModel{DB: db},
+		Programs:     ProgramModel{DB: db},
+		Schedule:     ScheduleModel{DB: db},
+		Measurements: BodyMeasurementModel{DB: db},
+		Sessions:     SessionModel{DB: db},
 	}
 }
@@ -0,0 +1,114 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+const (
+	PermissionExercisesRead  = "exercises:read"
+	PermissionExercisesWrite = "exercises:write"
+	PermissionMembersAdmin   = "members:admin"
+)
+
+var allowedPermissions = []string{
+	PermissionExercisesRead,
+	PermissionExercisesWrite,
+	PermissionMembersAdmin,
+}
+
+type Permissions []string
+
+func (p Permissions) Include(code string) bool {
+	for i := range p {
+		if code == p[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidatePermissions(v *validator.Validator, codes []string) {
+	v.Check(len(codes) > 0, "permissions", "must contain at least one permission")
+	v.Check(validator.Unique(codes), "permissions", "must not contain duplicate values")
+
+	for _, code := range codes {
+		v.Check(validator.PermittedValue(code, allowedPermissions...), "permissions", "invalid permission code")
+	}
+}
+
+type PermissionModel struct {
+	DB *sql.DB
+}
+
+func (m PermissionModel) GetAllForMember(memberID int64) (Permissions, error) {
+	query := `
+		SELECT permissions.code
+		FROM permissions
+		INNER JOIN members_permissions ON members_permissions.permission_id = permissions.id
+		WHERE members_permissions.member_id = $1
+		ORDER BY permissions.code
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := Permissions{}
+
+	for rows.Next() {
+		var permission string
+
+		err := rows.Scan(&permission)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (m PermissionModel) AddForMember(memberID int64, codes ...string) error {
+	query := `
+		INSERT INTO members_permissions (member_id, permission_id)
+		SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)
+		ON CONFLICT DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, memberID, pq.Array(codes))
+	return err
+}
+
+func (m PermissionModel) RemoveForMember(memberID int64, codes ...string) error {
+	query := `
+		DELETE FROM members_permissions
+		USING permissions
+		WHERE members_permissions.permission_id = permissions.id
+		AND members_permissions.member_id = $1
+		AND permissions.code = ANY($2)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, memberID, pq.Array(codes))
+	return err
+}
@@ -0,0 +1,898 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+const (
+	ProgressionNone       = "none"
+	ProgressionLinear     = "linear"
+	ProgressionPercentage = "percentage"
+
+	DefaultProgramIncrement = 2.5
+	DefaultDeloadPercentage = 60
+	trainingMaxPercentage   = 90
+	plateIncrement          = 2.5
+	plateIncrementPounds    = 5
+)
+
+type percentageSet struct {
+	percentage  float64
+	repetitions int
+	amrap       bool
+}
+
+var percentageWeeks = [][]percentageSet{
+	{{65, 5, false}, {75, 5, false}, {85, 5, true}},
+	{{70, 3, false}, {80, 3, false}, {90, 3, true}},
+	{{75, 5, false}, {85, 3, false}, {95, 1, true}},
+	{{40, 5, false}, {50, 5, false}, {60, 5, false}},
+}
+
+type Program struct {
+	ID               int64         `json:"id"`
+	MemberID         int64         `json:"member_id"`
+	Name             string        `json:"name"`
+	Description      string        `json:"description"`
+	Progression      string        `json:"progression"`
+	Increment        float64       `json:"increment"`
+	DeloadPercentage float64       `json:"deload_percentage"`
+	WeightUnit       string        `json:"weight_unit,omitempty"`
+	Weeks            int           `json:"weeks"`
+	Days             []*ProgramDay `json:"days"`
+	CreatedAt        time.Time     `json:"created_at"`
+	Version          int           `json:"-"`
+}
+
+type ProgramDay struct {
+	ID          int64  `json:"id"`
+	Week        int    `json:"week"`
+	Day         int    `json:"day"`
+	RoutineID   int64  `json:"routine_id"`
+	RoutineName string `json:"routine_name,omitempty"`
+	Deload      bool   `json:"deload"`
+}
+
+type Enrollment struct {
+	MemberID  int64     `json:"member_id"`
+	ProgramID int64     `json:"program_id"`
+	StartedAt time.Time `json:"started_at"`
+	Program   *Program  `json:"program,omitempty"`
+}
+
+type PrescribedSession struct {
+	ProgramID   int64            `json:"program_id"`
+	ProgramName string           `json:"program_name"`
+	Cycle       int              `json:"cycle"`
+	Week        int              `json:"week"`
+	Day         int              `json:"day"`
+	Deload      bool             `json:"deload"`
+	RoutineID   int64            `json:"routine_id"`
+	RoutineName string           `json:"routine_name"`
+	WeightUnit  string           `json:"weight_unit,omitempty"`
+	Sets        []*PrescribedSet `json:"sets"`
+}
+
+type PrescribedSet struct {
+	ExerciseID      int64    `json:"exercise_id"`
+	ExerciseName    string   `json:"exercise_name"`
+	Set             int      `json:"set"`
+	Repetitions     int      `json:"repetitions"`
+	Weight          float64  `json:"weight"`
+	DurationSeconds *int     `json:"duration_seconds,omitempty"`
+	DistanceMeters  *float64 `json:"distance_meters,omitempty"`
+	SetType         string   `json:"set_type"`
+}
+
+func (p *Program) ConvertWeights(unit string) {
+	p.WeightUnit = unit
+	p.Increment = FromKilograms(p.Increment, unit)
+}
+
+func (p *Program) sortDays() {
+	sort.Slice(p.Days, func(i, j int) bool {
+		if p.Days[i].Week != p.Days[j].Week {
+			return p.Days[i].Week < p.Days[j].Week
+		}
+		return p.Days[i].Day < p.Days[j].Day
+	})
+
+	p.Weeks = 0
+	for _, day := range p.Days {
+		if day.Week > p.Weeks {
+			p.Weeks = day.Week
+		}
+	}
+}
+
+func (p *Program) DayAt(completedSessions int) (*ProgramDay, int) {
+	if len(p.Days) == 0 {
+		return nil, 0
+	}
+
+	return p.Days[completedSessions%len(p.Days)], completedSessions/len(p.Days) + 1
+}
+
+func (s *PrescribedSession) ConvertWeights(unit string) {
+	s.WeightUnit = unit
+	for _, set := range s.Sets {
+		set.Weight = FromKilograms(set.Weight, unit)
+	}
+}
+
+func ValidateProgram(v *validator.Validator, program *Program) {
+	v.Check(program.Name != "", "name", "must be provided")
+	v.Check(len(program.Name) <= 100, "name", "must not be more than 100 bytes long")
+	v.Check(len(program.Description) <= 1000, "description", "must not be more than 1000 bytes long")
+	v.Check(validator.PermittedValue(program.Progression, ProgressionNone, ProgressionLinear, ProgressionPercentage), "progression", "must be one of none, linear or percentage")
+	v.Check(program.Increment >= 0, "increment", "must not be negative")
+	v.Check(program.Increment <= 50, "increment", "must not be more than 50 kg")
+	v.Check(program.DeloadPercentage > 0 && program.DeloadPercentage <= 100, "deload_percentage", "must be between 1 and 100")
+	v.Check(len(program.Days) > 0, "days", "must contain at least one day")
+	v.Check(len(program.Days) <= 364, "days", "must not contain more than 364 days")
+
+	slots := []string{}
+
+	for i, day := range program.Days {
+		prefix := fmt.Sprintf("days[%d].", i)
+
+		if day == nil {
+			v.AddError(strings.TrimSuffix(prefix, "."), "must be provided")
+			continue
+		}
+
+		v.Check(day.Week >= 1 && day.Week <= 52, prefix+"week", "must be between 1 and 52")
+		v.Check(day.Day >= 1 && day.Day <= 7, prefix+"day", "must be between 1 and 7")
+		v.Check(day.RoutineID > 0, prefix+"routine_id", "must be provided")
+
+		slots = append(slots, fmt.Sprintf("%d-%d", day.Week, day.Day))
+	}
+
+	v.Check(validator.Unique(slots), "days", "must not contain the same week and day twice")
+}
+
+type ProgramModel struct {
+	DB *sql.DB
+}
+
+func (m ProgramModel) Insert(program *Program) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO programs (member_id, name, description, progression, increment, deload_percentage)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, version
+	`
+
+	args := []interface{}{program.MemberID, program.Name, program.Description, program.Progression, program.Increment, program.DeloadPercentage}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&program.ID, &program.CreatedAt, &program.Version)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = insertProgramDays(ctx, tx, program)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m ProgramModel) Get(id, memberID int64) (*Program, error) {
+	query := `
+		SELECT id, member_id, name, description, progression, increment, deload_percentage, created_at, version
+		FROM programs
+		WHERE id = $1 AND member_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var program Program
+
+	err := m.DB.QueryRowContext(ctx, query, id, memberID).Scan(
+		&program.ID,
+		&program.MemberID,
+		&program.Name,
+		&program.Description,
+		&program.Progression,
+		&program.Increment,
+		&program.DeloadPercentage,
+		&program.CreatedAt,
+		&program.Version,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	days, err := m.getDays(ctx, []int64{program.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	program.Days = days[program.ID]
+	if program.Days == nil {
+		program.Days = []*ProgramDay{}
+	}
+	program.sortDays()
+
+	return &program, nil
+}
+
+func (m ProgramModel) GetAllForMember(memberID int64, filters Filters) ([]*Program, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, member_id, name, description, progression, increment, deload_percentage, created_at, version
+		FROM programs
+		WHERE member_id = $1
+		ORDER BY %s %s, id ASC
+		LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, memberID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	programs := []*Program{}
+	programIDs := []int64{}
+
+	for rows.Next() {
+		var program Program
+
+		err := rows.Scan(
+			&totalRecords,
+			&program.ID,
+			&program.MemberID,
+			&program.Name,
+			&program.Description,
+			&program.Progression,
+			&program.Increment,
+			&program.DeloadPercentage,
+			&program.CreatedAt,
+			&program.Version,
+		)
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		programs = append(programs, &program)
+		programIDs = append(programIDs, program.ID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	days, err := m.getDays(ctx, programIDs)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	for _, program := range programs {
+		program.Days = days[program.ID]
+		if program.Days == nil {
+			program.Days = []*ProgramDay{}
+		}
+		program.sortDays()
+	}
+
+	return programs, metadata, nil
+}
+
+func (m ProgramModel) Update(program *Program) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE programs
+		SET name = $1, description = $2, progression = $3, increment = $4, deload_percentage = $5, version = version + 1
+		WHERE id = $6 AND member_id = $7 AND version = $8
+		RETURNING version
+	`
+
+	args := []interface{}{
+		program.Name,
+		program.Description,
+		program.Progression,
+		program.Increment,
+		program.DeloadPercentage,
+		program.ID,
+		program.MemberID,
+		program.Version,
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&program.Version)
+	if err != nil {
+		tx.Rollback()
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM program_days WHERE program_id = $1`, program.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = insertProgramDays(ctx, tx, program)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m ProgramModel) Delete(id, memberID int64) error {
+	query := `
+		DELETE FROM programs
+		WHERE id = $1 AND member_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, memberID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m ProgramModel) Enroll(enrollment *Enrollment) error {
+	query := `
+		INSERT INTO program_enrollments (member_id, program_id, started_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (member_id) DO UPDATE
+		SET program_id = EXCLUDED.program_id, started_at = EXCLUDED.started_at, created_at = NOW()
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, enrollment.MemberID, enrollment.ProgramID, enrollment.StartedAt)
+	return err
+}
+
+func (m ProgramModel) GetEnrollment(memberID int64) (*Enrollment, error) {
+	query := `
+		SELECT member_id, program_id, started_at
+		FROM program_enrollments
+		WHERE member_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var enrollment Enrollment
+
+	err := m.DB.QueryRowContext(ctx, query, memberID).Scan(&enrollment.MemberID, &enrollment.ProgramID, &enrollment.StartedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &enrollment, nil
+}
+
+func (m ProgramModel) Unenroll(memberID int64) error {
+	query := `
+		DELETE FROM program_enrollments
+		WHERE member_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, memberID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m ProgramModel) CountSessions(enrollment *Enrollment) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM workouts
+		WHERE member_id = $1 AND program_id = $2 AND date >= $3
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var count int
+
+	err := m.DB.QueryRowContext(ctx, query, enrollment.MemberID, enrollment.ProgramID, enrollment.StartedAt).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (m ProgramModel) Prescribe(enrollment *Enrollment, day *ProgramDay, cycle int, routine *Routine, unit string) (*PrescribedSession, error) {
+	program := enrollment.Program
+
+	session := &PrescribedSession{
+		ProgramID:   program.ID,
+		ProgramName: program.Name,
+		Cycle:       cycle,
+		Week:        day.Week,
+		Day:         day.Day,
+		Deload:      day.Deload,
+		RoutineID:   routine.ID,
+		RoutineName: routine.Name,
+		Sets:        []*PrescribedSet{},
+	}
+
+	exerciseIDs := []int64{}
+	for _, exercise := range routine.Exercises {
+		exerciseIDs = append(exerciseIDs, exercise.ExerciseID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	measurementTypes, err := m.getMeasurementTypes(ctx, exerciseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastSessions map[int64][]*WorkoutDetail
+	var oneRepMaxes map[int64]float64
+
+	switch program.Progression {
+	case ProgressionLinear:
+		var deloadWorkoutIDs []int64
+
+		deloadWorkoutIDs, err = m.getDeloadWorkoutIDs(ctx, enrollment)
+		if err != nil {
+			return nil, err
+		}
+
+		lastSessions, err = m.getLastSessions(ctx, routine.MemberID, exerciseIDs, deloadWorkoutIDs)
+	case ProgressionPercentage:
+		oneRepMaxes, err = m.getOneRepMaxes(ctx, routine.MemberID, exerciseIDs)
+		if day.Week%len(percentageWeeks) == 0 {
+			session.Deload = true
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, exercise := range routine.Exercises {
+		set := PrescribedSet{
+			ExerciseID:      exercise.ExerciseID,
+			ExerciseName:    exercise.ExerciseName,
+			Repetitions:     exercise.TargetRepetitions,
+			Weight:          exercise.TargetWeight,
+			DurationSeconds: exercise.TargetDurationSeconds,
+			DistanceMeters:  exercise.TargetDistanceMeters,
+			SetType:         SetTypeWorking,
+		}
+
+		if measurementTypes[exercise.ExerciseID] != MeasurementWeightReps {
+			session.appendSets(set, exercise.TargetSets)
+			continue
+		}
+
+		switch program.Progression {
+		case ProgressionLinear:
+			set.Weight = linearWeight(exercise, lastSessions[exercise.ExerciseID], program.Increment)
+		case ProgressionPercentage:
+			trainingMax := exercise.TargetWeight
+			if oneRepMax, ok := oneRepMaxes[exercise.ExerciseID]; ok {
+				trainingMax = oneRepMax * trainingMaxPercentage / 100
+			}
+
+			for i, scheme := range percentageWeeks[(day.Week-1)%len(percentageWeeks)] {
+				set.Set = i + 1
+				set.Repetitions = scheme.repetitions
+				set.Weight = roundToPlates(trainingMax*scheme.percentage/100, unit)
+				set.SetType = SetTypeWorking
+				if scheme.amrap {
+					set.SetType = SetTypeAMRAP
+				}
+
+				prescribed := set
+				session.Sets = append(session.Sets, &prescribed)
+			}
+			continue
+		}
+
+		if day.Deload {
+			set.Weight = roundToPlates(set.Weight*program.DeloadPercentage/100, unit)
+		}
+
+		session.appendSets(set, exercise.TargetSets)
+	}
+
+	return session, nil
+}
+
+func (s *PrescribedSession) Details() []*WorkoutDetail {
+	details := []*WorkoutDetail{}
+
+	for _, set := range s.Sets {
+		details = append(details, &WorkoutDetail{
+			ExerciseID:      set.ExerciseID,
+			Set:             set.Set,
+			Repetitions:     set.Repetitions,
+			Weight:          set.Weight,
+			DurationSeconds: set.DurationSeconds,
+			DistanceMeters:  set.DistanceMeters,
+			SetType:         set.SetType,
+			Planned:         true,
+		})
+	}
+
+	return details
+}
+
+func (s *PrescribedSession) appendSets(set PrescribedSet, count int) {
+	for i := 1; i <= count; i++ {
+		prescribed := set
+		prescribed.Set = i
+		s.Sets = append(s.Sets, &prescribed)
+	}
+}
+
+func linearWeight(exercise *RoutineExercise, lastSession []*WorkoutDetail, increment float64) float64 {
+	if len(lastSession) == 0 {
+		return exercise.TargetWeight
+	}
+
+	weight := 0.0
+	succeeded := len(lastSession) >= exercise.TargetSets
+
+	for _, detail := range lastSession {
+		weight = math.Max(weight, detail.Weight)
+		if detail.Repetitions < exercise.TargetRepetitions {
+			succeeded = false
+		}
+	}
+
+	if succeeded {
+		weight += increment
+	}
+
+	return weight
+}
+
+func roundToPlates(weight float64, unit string) float64 {
+	if unit == UnitPounds {
+		pounds := FromKilograms(weight, unit)
+		return ToKilograms(math.Round(pounds/plateIncrementPounds)*plateIncrementPounds, unit)
+	}
+
+	return math.Round(weight/plateIncrement) * plateIncrement
+}
+
+func (m ProgramModel) getMeasurementTypes(ctx context.Context, exerciseIDs []int64) (map[int64]string, error) {
+	query := `
+		SELECT id, measurement_type
+		FROM exercises
+		WHERE id = ANY($1)
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(exerciseIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	measurementTypes := make(map[int64]string)
+
+	for rows.Next() {
+		var id int64
+		var measurementType string
+
+		err := rows.Scan(&id, &measurementType)
+		if err != nil {
+			return nil, err
+		}
+
+		measurementTypes[id] = measurementType
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return measurementTypes, nil
+}
+
+func (m ProgramModel) getDeloadWorkoutIDs(ctx context.Context, enrollment *Enrollment) ([]int64, error) {
+	query := `
+		SELECT id
+		FROM workouts
+		WHERE member_id = $1 AND program_id = $2 AND date >= $3
+		ORDER BY date, id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, enrollment.MemberID, enrollment.ProgramID, enrollment.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workoutIDs := []int64{}
+
+	for i := 0; rows.Next(); i++ {
+		var id int64
+
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		if day, _ := enrollment.Program.DayAt(i); day != nil && day.Deload {
+			workoutIDs = append(workoutIDs, id)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return workoutIDs, nil
+}
+
+func (m ProgramModel) getLastSessions(ctx context.Context, memberID int64, exerciseIDs, excludedWorkoutIDs []int64) (map[int64][]*WorkoutDetail, error) {
+	query := `
+		SELECT wd.exercise_id, wd.weight, wd.repetitions
+		FROM workout_details wd
+		JOIN workouts w
+		ON w.id = wd.workout_id
+		WHERE w.member_id = $1
+		AND wd.exercise_id = ANY($2)
+		AND wd.set_type <> 'warm_up'
+		AND NOT wd.planned
+		AND w.id = (
+			SELECT w2.id
+			FROM workouts w2
+			JOIN workout_details wd2
+			ON wd2.workout_id = w2.id
+			WHERE w2.member_id = $1
+			AND wd2.exercise_id = wd.exercise_id
+			AND wd2.set_type <> 'warm_up'
+			AND NOT wd2.planned
+			AND NOT (w2.id = ANY($3))
+			ORDER BY w2.date DESC, w2.id DESC
+			LIMIT 1
+		)
+		ORDER BY wd.exercise_id, wd.position
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, memberID, pq.Array(exerciseIDs), pq.Array(excludedWorkoutIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make(map[int64][]*WorkoutDetail)
+
+	for rows.Next() {
+		var detail WorkoutDetail
+
+		err := rows.Scan(&detail.ExerciseID, &detail.Weight, &detail.Repetitions)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions[detail.ExerciseID] = append(sessions[detail.ExerciseID], &detail)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (m ProgramModel) getOneRepMaxes(ctx context.Context, memberID int64, exerciseIDs []int64) (map[int64]float64, error) {
+	query := `
+		SELECT wd.exercise_id, MAX(CASE
+			WHEN wd.repetitions <= 1 THEN wd.weight
+			ELSE wd.weight * (1 + wd.repetitions / 30.0)
+		END)
+		FROM workout_details wd
+		JOIN workouts w
+		ON w.id = wd.workout_id
+		WHERE w.member_id = $1
+		AND wd.exercise_id = ANY($2)
+		AND wd.set_type <> 'warm_up'
+		AND NOT wd.planned
+		AND wd.weight > 0
+		AND wd.repetitions > 0
+		GROUP BY wd.exercise_id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, memberID, pq.Array(exerciseIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	oneRepMaxes := make(map[int64]float64)
+
+	for rows.Next() {
+		var id int64
+		var oneRepMax float64
+
+		err := rows.Scan(&id, &oneRepMax)
+		if err != nil {
+			return nil, err
+		}
+
+		oneRepMaxes[id] = oneRepMax
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return oneRepMaxes, nil
+}
+
+func insertProgramDays(ctx context.Context, tx *sql.Tx, program *Program) error {
+	routineIDs := []int64{}
+	seen := make(map[int64]bool)
+
+	for _, day := range program.Days {
+		if !seen[day.RoutineID] {
+			seen[day.RoutineID] = true
+			routineIDs = append(routineIDs, day.RoutineID)
+		}
+	}
+
+	var ownedRoutines int
+
+	err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM routines WHERE id = ANY($1) AND member_id = $2`, pq.Array(routineIDs), program.MemberID).Scan(&ownedRoutines)
+	if err != nil {
+		return err
+	}
+
+	if ownedRoutines != len(routineIDs) {
+		return ErrInvalidRoutine
+	}
+
+	values := []string{}
+	args := []interface{}{}
+
+	for _, day := range program.Days {
+		rowArgs := []interface{}{program.ID, day.Week, day.Day, day.RoutineID, day.Deload}
+		values = append(values, "("+placeholders(len(args)+1, len(rowArgs))+")")
+		args = append(args, rowArgs...)
+	}
+
+	query := `
+		INSERT INTO program_days (program_id, week, day, routine_id, deload)
+		VALUES ` + strings.Join(values, ", ") + `
+		RETURNING id`
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for i := 0; rows.Next(); i++ {
+		err = rows.Scan(&program.Days[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	program.sortDays()
+
+	return nil
+}
+
+func (m ProgramModel) getDays(ctx context.Context, programIDs []int64) (map[int64][]*ProgramDay, error) {
+	query := `
+		SELECT pd.program_id, pd.id, pd.week, pd.day, pd.routine_id, r.name, pd.deload
+		FROM program_days pd
+		JOIN routines r
+		ON r.id = pd.routine_id
+		WHERE pd.program_id = ANY($1)
+		ORDER BY pd.program_id, pd.week, pd.day
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(programIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make(map[int64][]*ProgramDay)
+
+	for rows.Next() {
+		var programID int64
+		var day ProgramDay
+
+		err := rows.Scan(&programID, &day.ID, &day.Week, &day.Day, &day.RoutineID, &day.RoutineName, &day.Deload)
+		if err != nil {
+			return nil, err
+		}
+
+		days[programID] = append(days[programID], &day)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
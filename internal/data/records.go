@@ -0,0 +1,502 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+const (
+	RecordMaxWeight       = "max_weight"
+	RecordRepsAtWeight    = "max_reps_at_weight"
+	RecordEstimated1RM    = "estimated_1rm"
+	RecordSessionVolume   = "max_session_volume"
+	FormulaEpley          = "epley"
+	FormulaBrzycki        = "brzycki"
+	brzyckiMaxRepetition  = 36
+	recordInsertBatchSize = 1000
+)
+
+var recordFormulas = []string{FormulaEpley, FormulaBrzycki}
+
+type PersonalRecord struct {
+	ID           int64     `json:"id"`
+	MemberID     int64     `json:"-"`
+	ExerciseID   int64     `json:"exercise_id"`
+	ExerciseName string    `json:"exercise_name,omitempty"`
+	RecordType   string    `json:"record_type"`
+	Formula      string    `json:"formula,omitempty"`
+	Value        float64   `json:"value"`
+	Weight       *float64  `json:"weight,omitempty"`
+	Repetitions  *int      `json:"repetitions,omitempty"`
+	WorkoutID    int64     `json:"workout_id"`
+	AchievedAt   time.Time `json:"achieved_at"`
+}
+
+func (r *PersonalRecord) ConvertWeights(unit string) {
+	if r.RecordType != RecordRepsAtWeight {
+		r.Value = FromKilograms(r.Value, unit)
+	}
+
+	if r.Weight != nil {
+		weight := FromKilograms(*r.Weight, unit)
+		r.Weight = &weight
+	}
+}
+
+func ValidateFormula(v *validator.Validator, formula string) {
+	v.Check(validator.PermittedValue(formula, recordFormulas...), "formula", "must be either epley or brzycki")
+}
+
+func FilterRecordsByFormula(records []*PersonalRecord, formula string) []*PersonalRecord {
+	filtered := []*PersonalRecord{}
+
+	for _, record := range records {
+		if record.Formula == "" || record.Formula == formula {
+			filtered = append(filtered, record)
+		}
+	}
+
+	return filtered
+}
+
+func EstimateOneRepMax(weight float64, repetitions int, formula string) float64 {
+	if repetitions <= 1 {
+		return weight
+	}
+
+	switch formula {
+	case FormulaBrzycki:
+		if repetitions > brzyckiMaxRepetition {
+			repetitions = brzyckiMaxRepetition
+		}
+		return weight * 36 / float64(37-repetitions)
+	default:
+		return weight * (1 + float64(repetitions)/30)
+	}
+}
+
+type PersonalRecordModel struct {
+	DB *sql.DB
+}
+
+func (m PersonalRecordModel) GetCurrent(memberID, exerciseID int64, formula string) ([]*PersonalRecord, error) {
+	query := `
+		SELECT DISTINCT ON (pr.exercise_id, pr.record_type, pr.formula, CASE WHEN pr.record_type = 'max_reps_at_weight' THEN pr.weight END)
+			pr.id, pr.member_id, pr.exercise_id, e.name, pr.record_type, pr.formula, pr.value, pr.weight, pr.repetitions, pr.workout_id, pr.achieved_at
+		FROM personal_records pr
+		JOIN exercises e
+		ON e.id = pr.exercise_id
+		WHERE pr.member_id = $1
+		AND (pr.exercise_id = $2 OR $2 = 0)
+		AND (pr.formula = '' OR pr.formula = $3)
+		ORDER BY pr.exercise_id, pr.record_type, pr.formula, CASE WHEN pr.record_type = 'max_reps_at_weight' THEN pr.weight END,
+			pr.value DESC, pr.achieved_at ASC
+	`
+
+	return m.query(query, memberID, exerciseID, formula)
+}
+
+func (m PersonalRecordModel) GetHistory(memberID, exerciseID int64, formula string) ([]*PersonalRecord, error) {
+	query := `
+		SELECT pr.id, pr.member_id, pr.exercise_id, e.name, pr.record_type, pr.formula, pr.value, pr.weight, pr.repetitions, pr.workout_id, pr.achieved_at
+		FROM personal_records pr
+		JOIN exercises e
+		ON e.id = pr.exercise_id
+		WHERE pr.member_id = $1
+		AND pr.exercise_id = $2
+		AND (pr.formula = '' OR pr.formula = $3)
+		ORDER BY pr.achieved_at, pr.id
+	`
+
+	return m.query(query, memberID, exerciseID, formula)
+}
+
+func (m PersonalRecordModel) query(query string, args ...interface{}) ([]*PersonalRecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []*PersonalRecord{}
+
+	for rows.Next() {
+		var record PersonalRecord
+
+		err := rows.Scan(
+			&record.ID,
+			&record.MemberID,
+			&record.ExerciseID,
+			&record.ExerciseName,
+			&record.RecordType,
+			&record.Formula,
+			&record.Value,
+			&record.Weight,
+			&record.Repetitions,
+			&record.WorkoutID,
+			&record.AchievedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, &record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+type recordKey struct {
+	exerciseID int64
+	recordType string
+	formula    string
+	weight     float64
+}
+
+func detectPersonalRecords(ctx context.Context, tx *sql.Tx, workout *Workout) ([]*PersonalRecord, error) {
+	exerciseIDs := []int64{}
+	for _, detail := range workout.Details {
+		exerciseIDs = append(exerciseIDs, detail.ExerciseID)
+	}
+
+	weighted, err := getWeightedExercises(ctx, tx, exerciseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, order := proposeRecords(workout, weighted)
+	if len(candidates) == 0 {
+		return []*PersonalRecord{}, nil
+	}
+
+	laterQuery := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM workouts w
+			JOIN workout_details wd
+			ON wd.workout_id = w.id
+			WHERE w.member_id = $1 AND w.date > $2 AND wd.exercise_id = ANY($3) AND NOT wd.planned
+		)
+	`
+
+	var later bool
+
+	err = tx.QueryRowContext(ctx, laterQuery, workout.MemberID, workout.Date, pq.Array(exerciseIDs)).Scan(&later)
+	if err != nil {
+		return nil, err
+	}
+
+	if later {
+		records, err := recomputePersonalRecords(ctx, tx, workout.MemberID, exerciseIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		workoutRecords := []*PersonalRecord{}
+		for _, record := range records {
+			if record.WorkoutID == workout.ID {
+				workoutRecords = append(workoutRecords, record)
+			}
+		}
+
+		return workoutRecords, nil
+	}
+
+	bestsQuery := `
+		SELECT exercise_id, record_type, formula, CASE WHEN record_type = 'max_reps_at_weight' THEN weight ELSE 0 END, MAX(value)
+		FROM personal_records
+		WHERE member_id = $1 AND exercise_id = ANY($2)
+		GROUP BY 1, 2, 3, 4
+	`
+
+	rows, err := tx.QueryContext(ctx, bestsQuery, workout.MemberID, pq.Array(exerciseIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	bests := make(map[recordKey]float64)
+
+	for rows.Next() {
+		var key recordKey
+		var best float64
+
+		err := rows.Scan(&key.exerciseID, &key.recordType, &key.formula, &key.weight, &best)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		bests[key] = best
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	records := newRecords(candidates, order, bests)
+
+	err = insertPersonalRecords(ctx, tx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func recomputePersonalRecords(ctx context.Context, tx *sql.Tx, memberID int64, exerciseIDs []int64) ([]*PersonalRecord, error) {
+	query := `
+		DELETE FROM personal_records
+		WHERE member_id = $1 AND exercise_id = ANY($2)
+	`
+
+	_, err := tx.ExecContext(ctx, query, memberID, pq.Array(exerciseIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	weighted, err := getWeightedExercises(ctx, tx, exerciseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT w.id, w.date, wd.exercise_id, wd.repetitions, wd.weight, wd.set_type
+		FROM workout_details wd
+		JOIN workouts w
+		ON w.id = wd.workout_id
+		WHERE w.member_id = $1 AND wd.exercise_id = ANY($2) AND NOT wd.planned
+		ORDER BY w.date, w.id, wd.position
+	`
+
+	rows, err := tx.QueryContext(ctx, query, memberID, pq.Array(exerciseIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	workouts := []*Workout{}
+
+	for rows.Next() {
+		var workoutID int64
+		var date time.Time
+		var detail WorkoutDetail
+
+		err := rows.Scan(&workoutID, &date, &detail.ExerciseID, &detail.Repetitions, &detail.Weight, &detail.SetType)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if len(workouts) == 0 || workouts[len(workouts)-1].ID != workoutID {
+			workouts = append(workouts, &Workout{ID: workoutID, MemberID: memberID, Date: date})
+		}
+
+		workout := workouts[len(workouts)-1]
+		workout.Details = append(workout.Details, &detail)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	bests := make(map[recordKey]float64)
+	records := []*PersonalRecord{}
+
+	for _, workout := range workouts {
+		candidates, order := proposeRecords(workout, weighted)
+		records = append(records, newRecords(candidates, order, bests)...)
+	}
+
+	err = insertPersonalRecords(ctx, tx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func getWeightedExercises(ctx context.Context, tx *sql.Tx, exerciseIDs []int64) (map[int64]bool, error) {
+	query := `
+		SELECT id
+		FROM exercises
+		WHERE id = ANY($1) AND measurement_type = $2
+	`
+
+	rows, err := tx.QueryContext(ctx, query, pq.Array(exerciseIDs), MeasurementWeightReps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weighted := make(map[int64]bool)
+
+	for rows.Next() {
+		var id int64
+
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		weighted[id] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return weighted, nil
+}
+
+func proposeRecords(workout *Workout, weighted map[int64]bool) (map[recordKey]*PersonalRecord, []recordKey) {
+	candidates := make(map[recordKey]*PersonalRecord)
+	order := []recordKey{}
+
+	propose := func(key recordKey, value, weight float64, repetitions int) {
+		current, ok := candidates[key]
+		if ok && current.Value >= value {
+			return
+		}
+
+		if !ok {
+			order = append(order, key)
+		}
+
+		candidates[key] = &PersonalRecord{
+			MemberID:    workout.MemberID,
+			ExerciseID:  key.exerciseID,
+			RecordType:  key.recordType,
+			Formula:     key.formula,
+			Value:       value,
+			Weight:      &weight,
+			Repetitions: &repetitions,
+			WorkoutID:   workout.ID,
+			AchievedAt:  workout.Date,
+		}
+	}
+
+	volumes := make(map[int64]float64)
+	volumeOrder := []int64{}
+
+	for _, detail := range workout.Details {
+		if !weighted[detail.ExerciseID] || detail.SetType == SetTypeWarmUp || detail.Planned {
+			continue
+		}
+
+		if detail.Weight <= 0 || detail.Repetitions <= 0 {
+			continue
+		}
+
+		propose(recordKey{detail.ExerciseID, RecordMaxWeight, "", 0}, detail.Weight, detail.Weight, detail.Repetitions)
+		propose(recordKey{detail.ExerciseID, RecordRepsAtWeight, "", detail.Weight}, float64(detail.Repetitions), detail.Weight, detail.Repetitions)
+
+		for _, formula := range recordFormulas {
+			estimate := EstimateOneRepMax(detail.Weight, detail.Repetitions, formula)
+			propose(recordKey{detail.ExerciseID, RecordEstimated1RM, formula, 0}, estimate, detail.Weight, detail.Repetitions)
+		}
+
+		if _, ok := volumes[detail.ExerciseID]; !ok {
+			volumeOrder = append(volumeOrder, detail.ExerciseID)
+		}
+		volumes[detail.ExerciseID] += detail.Weight * float64(detail.Repetitions)
+	}
+
+	for _, exerciseID := range volumeOrder {
+		key := recordKey{exerciseID, RecordSessionVolume, "", 0}
+		order = append(order, key)
+		candidates[key] = &PersonalRecord{
+			MemberID:   workout.MemberID,
+			ExerciseID: exerciseID,
+			RecordType: RecordSessionVolume,
+			Value:      volumes[exerciseID],
+			WorkoutID:  workout.ID,
+			AchievedAt: workout.Date,
+		}
+	}
+
+	return candidates, order
+}
+
+func newRecords(candidates map[recordKey]*PersonalRecord, order []recordKey, bests map[recordKey]float64) []*PersonalRecord {
+	records := []*PersonalRecord{}
+
+	for _, key := range order {
+		candidate := candidates[key]
+
+		if best, ok := bests[key]; ok && candidate.Value <= best {
+			continue
+		}
+
+		bests[key] = candidate.Value
+		records = append(records, candidate)
+	}
+
+	return records
+}
+
+func insertPersonalRecords(ctx context.Context, tx *sql.Tx, records []*PersonalRecord) error {
+	for start := 0; start < len(records); start += recordInsertBatchSize {
+		batch := records[start:min(start+recordInsertBatchSize, len(records))]
+
+		values := []string{}
+		args := []interface{}{}
+
+		for _, record := range batch {
+			rowArgs := []interface{}{
+				record.MemberID,
+				record.ExerciseID,
+				record.RecordType,
+				record.Formula,
+				record.Value,
+				record.Weight,
+				record.Repetitions,
+				record.WorkoutID,
+				record.AchievedAt,
+			}
+			values = append(values, "("+placeholders(len(args)+1, len(rowArgs))+")")
+			args = append(args, rowArgs...)
+		}
+
+		query := `
+		INSERT INTO personal_records (member_id, exercise_id, record_type, formula, value, weight, repetitions, workout_id, achieved_at)
+		VALUES ` + strings.Join(values, ", ") + `
+		RETURNING id`
+
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		for i := 0; rows.Next(); i++ {
+			err = rows.Scan(&batch[i].ID)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+		}
+
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
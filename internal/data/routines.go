@@ -0,0 +1,418 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+type Routine struct {
+	ID         int64              `json:"id"`
+	MemberID   int64              `json:"member_id"`
+	Name       string             `json:"name"`
+	Notes      string             `json:"notes"`
+	WeightUnit string             `json:"weight_unit,omitempty"`
+	Exercises  []*RoutineExercise `json:"exercises"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Version    int                `json:"-"`
+}
+
+type RoutineExercise struct {
+	ID                    int64    `json:"id"`
+	ExerciseID            int64    `json:"exercise_id"`
+	ExerciseName          string   `json:"exercise_name,omitempty"`
+	Position              int      `json:"position"`
+	TargetSets            int      `json:"target_sets"`
+	TargetRepetitions     int      `json:"target_repetitions"`
+	TargetWeight          float64  `json:"target_weight"`
+	TargetDurationSeconds *int     `json:"target_duration_seconds,omitempty"`
+	TargetDistanceMeters  *float64 `json:"target_distance_meters,omitempty"`
+	Notes                 string   `json:"notes,omitempty"`
+}
+
+func (r *Routine) ConvertWeights(unit string) {
+	r.WeightUnit = unit
+	for _, exercise := range r.Exercises {
+		exercise.TargetWeight = FromKilograms(exercise.TargetWeight, unit)
+	}
+}
+
+func (r *Routine) Details() []*WorkoutDetail {
+	details := []*WorkoutDetail{}
+
+	for _, exercise := range r.Exercises {
+		for set := 1; set <= exercise.TargetSets; set++ {
+			details = append(details, &WorkoutDetail{
+				ExerciseID:      exercise.ExerciseID,
+				Set:             set,
+				Repetitions:     exercise.TargetRepetitions,
+				Weight:          exercise.TargetWeight,
+				DurationSeconds: exercise.TargetDurationSeconds,
+				DistanceMeters:  exercise.TargetDistanceMeters,
+				SetType:         SetTypeWorking,
+				Planned:         true,
+			})
+		}
+	}
+
+	return details
+}
+
+func ValidateRoutine(v *validator.Validator, routine *Routine) {
+	v.Check(routine.Name != "", "name", "must be provided")
+	v.Check(len(routine.Name) <= 100, "name", "must not be more than 100 bytes long")
+	v.Check(len(routine.Notes) <= 1000, "notes", "must not be more than 1000 bytes long")
+	v.Check(len(routine.Exercises) > 0, "exercises", "must contain at least one exercise")
+	v.Check(len(routine.Exercises) <= 50, "exercises", "must not contain more than 50 exercises")
+}
+
+func ValidateRoutineExercise(v *validator.Validator, prefix string, exercise *RoutineExercise, measurementType string) {
+	v.Check(exercise.TargetSets > 0, prefix+"target_sets", "must be greater than zero")
+	v.Check(exercise.TargetSets <= 20, prefix+"target_sets", "must not be more than 20")
+	v.Check(exercise.TargetRepetitions >= 0, prefix+"target_repetitions", "must not be negative")
+	v.Check(exercise.TargetWeight >= 0, prefix+"target_weight", "must not be negative")
+
+	switch measurementType {
+	case MeasurementWeightReps, MeasurementBodyweightReps:
+		v.Check(exercise.TargetRepetitions > 0, prefix+"target_repetitions", "must be provided for this exercise")
+	case MeasurementTime:
+		v.Check(exercise.TargetDurationSeconds != nil, prefix+"target_duration_seconds", "must be provided for this exercise")
+	case MeasurementDistance:
+		v.Check(exercise.TargetDistanceMeters != nil, prefix+"target_distance_meters", "must be provided for this exercise")
+	}
+
+	if exercise.TargetDurationSeconds != nil {
+		v.Check(*exercise.TargetDurationSeconds > 0, prefix+"target_duration_seconds", "must be greater than zero")
+		v.Check(*exercise.TargetDurationSeconds <= 86_400, prefix+"target_duration_seconds", "must not be more than 24 hours")
+	}
+
+	if exercise.TargetDistanceMeters != nil {
+		v.Check(*exercise.TargetDistanceMeters > 0, prefix+"target_distance_meters", "must be greater than zero")
+		v.Check(*exercise.TargetDistanceMeters <= 1_000_000, prefix+"target_distance_meters", "must not be more than 1000 km")
+	}
+
+	v.Check(len(exercise.Notes) <= 500, prefix+"notes", "must not be more than 500 bytes long")
+}
+
+type RoutineModel struct {
+	DB *sql.DB
+}
+
+func (m RoutineModel) Insert(routine *Routine) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO routines (member_id, name, notes)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, version
+	`
+
+	err = tx.QueryRowContext(ctx, query, routine.MemberID, routine.Name, routine.Notes).Scan(&routine.ID, &routine.CreatedAt, &routine.Version)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = insertRoutineExercises(ctx, tx, routine)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m RoutineModel) Get(id, memberID int64) (*Routine, error) {
+	query := `
+		SELECT id, member_id, name, notes, created_at, version
+		FROM routines
+		WHERE id = $1 AND member_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var routine Routine
+
+	err := m.DB.QueryRowContext(ctx, query, id, memberID).Scan(
+		&routine.ID,
+		&routine.MemberID,
+		&routine.Name,
+		&routine.Notes,
+		&routine.CreatedAt,
+		&routine.Version,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	exercises, err := m.getExercises(ctx, []int64{routine.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	routine.Exercises = exercises[routine.ID]
+	if routine.Exercises == nil {
+		routine.Exercises = []*RoutineExercise{}
+	}
+
+	return &routine, nil
+}
+
+func (m RoutineModel) GetAllForMember(memberID int64, name string, filters Filters) ([]*Routine, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, member_id, name, notes, created_at, version
+		FROM routines
+		WHERE member_id = $1
+		AND (name ILIKE ('%%' || $2 || '%%') ESCAPE '\' OR $2 = '')
+		ORDER BY %s %s, id ASC
+		LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, memberID, escapeLike(name), filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	routines := []*Routine{}
+	routineIDs := []int64{}
+
+	for rows.Next() {
+		var routine Routine
+
+		err := rows.Scan(
+			&totalRecords,
+			&routine.ID,
+			&routine.MemberID,
+			&routine.Name,
+			&routine.Notes,
+			&routine.CreatedAt,
+			&routine.Version,
+		)
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		routines = append(routines, &routine)
+		routineIDs = append(routineIDs, routine.ID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	exercises, err := m.getExercises(ctx, routineIDs)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	for _, routine := range routines {
+		routine.Exercises = exercises[routine.ID]
+		if routine.Exercises == nil {
+			routine.Exercises = []*RoutineExercise{}
+		}
+	}
+
+	return routines, metadata, nil
+}
+
+func (m RoutineModel) Update(routine *Routine) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE routines
+		SET name = $1, notes = $2, version = version + 1
+		WHERE id = $3 AND member_id = $4 AND version = $5
+		RETURNING version
+	`
+
+	args := []interface{}{routine.Name, routine.Notes, routine.ID, routine.MemberID, routine.Version}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&routine.Version)
+	if err != nil {
+		tx.Rollback()
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM routine_exercises WHERE routine_id = $1`, routine.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = insertRoutineExercises(ctx, tx, routine)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m RoutineModel) Delete(id, memberID int64) error {
+	query := `
+		DELETE FROM routines
+		WHERE id = $1 AND member_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, memberID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func insertRoutineExercises(ctx context.Context, tx *sql.Tx, routine *Routine) error {
+	exerciseIDs := []int64{}
+	for _, exercise := range routine.Exercises {
+		exerciseIDs = append(exerciseIDs, exercise.ExerciseID)
+	}
+
+	err := checkExercises(ctx, tx, routine.MemberID, exerciseIDs)
+	if err != nil {
+		return err
+	}
+
+	values := []string{}
+	args := []interface{}{}
+
+	for i, exercise := range routine.Exercises {
+		exercise.Position = i + 1
+
+		rowArgs := []interface{}{
+			routine.ID,
+			exercise.ExerciseID,
+			exercise.Position,
+			exercise.TargetSets,
+			exercise.TargetRepetitions,
+			exercise.TargetWeight,
+			exercise.TargetDurationSeconds,
+			exercise.TargetDistanceMeters,
+			exercise.Notes,
+		}
+
+		values = append(values, "("+placeholders(len(args)+1, len(rowArgs))+")")
+		args = append(args, rowArgs...)
+	}
+
+	query := `
+		INSERT INTO routine_exercises (routine_id, exercise_id, position, target_sets, target_repetitions, target_weight,
+			target_duration_seconds, target_distance_meters, notes)
+		VALUES ` + strings.Join(values, ", ") + `
+		RETURNING id`
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for i := 0; rows.Next(); i++ {
+		err = rows.Scan(&routine.Exercises[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (m RoutineModel) getExercises(ctx context.Context, routineIDs []int64) (map[int64][]*RoutineExercise, error) {
+	query := `
+		SELECT re.routine_id, re.id, re.exercise_id, e.name, re.position, re.target_sets, re.target_repetitions,
+			re.target_weight, re.target_duration_seconds, re.target_distance_meters, re.notes
+		FROM routine_exercises re
+		JOIN exercises e
+		ON e.id = re.exercise_id
+		WHERE re.routine_id = ANY($1)
+		ORDER BY re.routine_id, re.position
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, pq.Array(routineIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := make(map[int64][]*RoutineExercise)
+
+	for rows.Next() {
+		var routineID int64
+		var exercise RoutineExercise
+
+		err := rows.Scan(
+			&routineID,
+			&exercise.ID,
+			&exercise.ExerciseID,
+			&exercise.ExerciseName,
+			&exercise.Position,
+			&exercise.TargetSets,
+			&exercise.TargetRepetitions,
+			&exercise.TargetWeight,
+			&exercise.TargetDurationSeconds,
+			&exercise.TargetDistanceMeters,
+			&exercise.Notes,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		exercises[routineID] = append(exercises[routineID], &exercise)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return exercises, nil
+}
@@ -0,0 +1,456 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+const (
+	ScheduleStatusPlanned   = "planned"
+	ScheduleStatusCompleted = "completed"
+	ScheduleStatusSkipped   = "skipped"
+
+	CalendarEntryScheduled = "scheduled"
+	CalendarEntryWorkout   = "workout"
+)
+
+type ScheduledWorkout struct {
+	ID        int64     `json:"id"`
+	MemberID  int64     `json:"member_id"`
+	Date      time.Time `json:"date"`
+	Title     string    `json:"title"`
+	Notes     string    `json:"notes"`
+	RoutineID *int64    `json:"routine_id,omitempty"`
+	Status    string    `json:"status"`
+	WorkoutID *int64    `json:"workout_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"-"`
+}
+
+type CalendarEntry struct {
+	Date               time.Time `json:"date"`
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Title              string    `json:"title,omitempty"`
+	Notes              string    `json:"notes,omitempty"`
+	ScheduledWorkoutID *int64    `json:"scheduled_workout_id,omitempty"`
+	WorkoutID          *int64    `json:"workout_id,omitempty"`
+	RoutineID          *int64    `json:"routine_id,omitempty"`
+}
+
+type Adherence struct {
+	Planned    int      `json:"planned"`
+	Completed  int      `json:"completed"`
+	Skipped    int      `json:"skipped"`
+	Missed     int      `json:"missed"`
+	Percentage *float64 `json:"percentage"`
+}
+
+type Streaks struct {
+	Current int `json:"current"`
+	Longest int `json:"longest"`
+}
+
+type Calendar struct {
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Entries   []*CalendarEntry `json:"entries"`
+	Adherence Adherence        `json:"adherence"`
+	Streaks   Streaks          `json:"streaks"`
+}
+
+func ValidateScheduledWorkout(v *validator.Validator, scheduled *ScheduledWorkout) {
+	v.Check(!scheduled.Date.IsZero(), "date", "must be provided")
+	v.Check(len(scheduled.Title) <= 100, "title", "must not be more than 100 bytes long")
+	v.Check(len(scheduled.Notes) <= 1000, "notes", "must not be more than 1000 bytes long")
+	v.Check(validator.PermittedValue(scheduled.Status, ScheduleStatusPlanned, ScheduleStatusCompleted, ScheduleStatusSkipped), "status", "must be one of planned, completed or skipped")
+
+	if scheduled.RoutineID != nil {
+		v.Check(*scheduled.RoutineID > 0, "routine_id", "must be a positive integer")
+	}
+}
+
+func ValidateScheduleStatus(v *validator.Validator, status string) {
+	if status != "" {
+		v.Check(validator.PermittedValue(status, ScheduleStatusPlanned, ScheduleStatusCompleted, ScheduleStatusSkipped), "status", "must be one of planned, completed or skipped")
+	}
+}
+
+type ScheduleModel struct {
+	DB *sql.DB
+}
+
+func (m ScheduleModel) Insert(scheduled *ScheduledWorkout) error {
+	query := `
+		INSERT INTO scheduled_workouts (member_id, date, title, notes, routine_id, status, workout_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, version
+	`
+
+	args := []interface{}{
+		scheduled.MemberID,
+		scheduled.Date,
+		scheduled.Title,
+		scheduled.Notes,
+		scheduled.RoutineID,
+		scheduled.Status,
+		scheduled.WorkoutID,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&scheduled.ID, &scheduled.CreatedAt, &scheduled.Version)
+}
+
+func (m ScheduleModel) Get(id, memberID int64) (*ScheduledWorkout, error) {
+	query := `
+		SELECT id, member_id, date, title, notes, routine_id, status, workout_id, created_at, version
+		FROM scheduled_workouts
+		WHERE id = $1 AND member_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var scheduled ScheduledWorkout
+
+	err := m.DB.QueryRowContext(ctx, query, id, memberID).Scan(
+		&scheduled.ID,
+		&scheduled.MemberID,
+		&scheduled.Date,
+		&scheduled.Title,
+		&scheduled.Notes,
+		&scheduled.RoutineID,
+		&scheduled.Status,
+		&scheduled.WorkoutID,
+		&scheduled.CreatedAt,
+		&scheduled.Version,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &scheduled, nil
+}
+
+func (m ScheduleModel) GetAll(memberID int64, workoutFilters WorkoutFilters, status string, filters Filters) ([]*ScheduledWorkout, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), id, member_id, date, title, notes, routine_id, status, workout_id, created_at, version
+		FROM scheduled_workouts
+		WHERE member_id = $1
+		AND ($2::timestamp IS NULL OR date >= $2)
+		AND ($3::timestamp IS NULL OR date < $3)
+		AND (status = $4 OR $4 = '')
+		ORDER BY %s %s, id ASC
+		LIMIT $5 OFFSET $6`, filters.sortColumn(), filters.sortDirection())
+
+	args := []interface{}{
+		memberID,
+		workoutFilters.From,
+		workoutFilters.to(),
+		status,
+		filters.limit(),
+		filters.offset(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	schedule := []*ScheduledWorkout{}
+
+	for rows.Next() {
+		var scheduled ScheduledWorkout
+
+		err := rows.Scan(
+			&totalRecords,
+			&scheduled.ID,
+			&scheduled.MemberID,
+			&scheduled.Date,
+			&scheduled.Title,
+			&scheduled.Notes,
+			&scheduled.RoutineID,
+			&scheduled.Status,
+			&scheduled.WorkoutID,
+			&scheduled.CreatedAt,
+			&scheduled.Version,
+		)
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		schedule = append(schedule, &scheduled)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return schedule, metadata, nil
+}
+
+func (m ScheduleModel) GetInRange(memberID int64, from, to time.Time) ([]*ScheduledWorkout, error) {
+	query := `
+		SELECT id, member_id, date, title, notes, routine_id, status, workout_id, created_at, version
+		FROM scheduled_workouts
+		WHERE member_id = $1 AND date >= $2::timestamp AND date < $3::timestamp
+		ORDER BY date, id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, memberID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedule := []*ScheduledWorkout{}
+
+	for rows.Next() {
+		var scheduled ScheduledWorkout
+
+		err := rows.Scan(
+			&scheduled.ID,
+			&scheduled.MemberID,
+			&scheduled.Date,
+			&scheduled.Title,
+			&scheduled.Notes,
+			&scheduled.RoutineID,
+			&scheduled.Status,
+			&scheduled.WorkoutID,
+			&scheduled.CreatedAt,
+			&scheduled.Version,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		schedule = append(schedule, &scheduled)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (m ScheduleModel) Update(scheduled *ScheduledWorkout) error {
+	query := `
+		UPDATE scheduled_workouts
+		SET date = $1, title = $2, notes = $3, routine_id = $4, status = $5, workout_id = $6, version = version + 1
+		WHERE id = $7 AND member_id = $8 AND version = $9
+		RETURNING version
+	`
+
+	args := []interface{}{
+		scheduled.Date,
+		scheduled.Title,
+		scheduled.Notes,
+		scheduled.RoutineID,
+		scheduled.Status,
+		scheduled.WorkoutID,
+		scheduled.ID,
+		scheduled.MemberID,
+		scheduled.Version,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&scheduled.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m ScheduleModel) Delete(id, memberID int64) error {
+	query := `
+		DELETE FROM scheduled_workouts
+		WHERE id = $1 AND member_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, memberID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m ScheduleModel) GetCalendar(memberID int64, from, to, today time.Time) (*Calendar, error) {
+	calendar := &Calendar{
+		From:    from,
+		To:      to,
+		Entries: []*CalendarEntry{},
+	}
+
+	query := `
+		SELECT date, type, status, title, notes, scheduled_workout_id, workout_id, routine_id
+		FROM (
+			SELECT s.date::timestamp AS date, 'scheduled' AS type, s.status, s.title, s.notes,
+				s.id AS scheduled_workout_id, s.workout_id, s.routine_id
+			FROM scheduled_workouts s
+			WHERE s.member_id = $1 AND s.date >= $2::timestamp AND s.date < $3::timestamp
+			UNION ALL
+			SELECT w.date, 'workout', 'completed', '', w.notes, NULL, w.id, NULL
+			FROM workouts w
+			WHERE w.member_id = $1 AND w.date >= $2 AND w.date < $3
+			AND NOT EXISTS (SELECT 1 FROM scheduled_workouts s WHERE s.workout_id = w.id)
+		) entries
+		ORDER BY date, scheduled_workout_id NULLS LAST, workout_id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, memberID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry CalendarEntry
+
+		err := rows.Scan(
+			&entry.Date,
+			&entry.Type,
+			&entry.Status,
+			&entry.Title,
+			&entry.Notes,
+			&entry.ScheduledWorkoutID,
+			&entry.WorkoutID,
+			&entry.RoutineID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		calendar.Entries = append(calendar.Entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	adherenceQuery := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'completed'),
+			COUNT(*) FILTER (WHERE status = 'skipped'),
+			COUNT(*) FILTER (WHERE status = 'planned' AND date < $4::timestamp)
+		FROM scheduled_workouts
+		WHERE member_id = $1 AND date >= $2::timestamp AND date < $3::timestamp
+	`
+
+	adherence := &calendar.Adherence
+
+	err = m.DB.QueryRowContext(ctx, adherenceQuery, memberID, from, to.AddDate(0, 0, 1), today).Scan(
+		&adherence.Planned,
+		&adherence.Completed,
+		&adherence.Skipped,
+		&adherence.Missed,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if due := adherence.Completed + adherence.Skipped + adherence.Missed; due > 0 {
+		percentage := math.Round(float64(adherence.Completed)/float64(due)*1000) / 10
+		adherence.Percentage = &percentage
+	}
+
+	calendar.Streaks, err = m.getStreaks(ctx, memberID, today)
+	if err != nil {
+		return nil, err
+	}
+
+	return calendar, nil
+}
+
+func (m ScheduleModel) getStreaks(ctx context.Context, memberID int64, today time.Time) (Streaks, error) {
+	query := `
+		SELECT status
+		FROM scheduled_workouts
+		WHERE member_id = $1
+		AND (date < $2::timestamp OR status <> 'planned')
+		AND date <= $2::timestamp
+		ORDER BY date, id
+	`
+
+	rows, err := m.DB.QueryContext(ctx, query, memberID, today)
+	if err != nil {
+		return Streaks{}, err
+	}
+	defer rows.Close()
+
+	var streaks Streaks
+
+	for rows.Next() {
+		var status string
+
+		err := rows.Scan(&status)
+		if err != nil {
+			return Streaks{}, err
+		}
+
+		if status == ScheduleStatusCompleted {
+			streaks.Current++
+			streaks.Longest = max(streaks.Longest, streaks.Current)
+		} else {
+			streaks.Current = 0
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return Streaks{}, err
+	}
+
+	return streaks, nil
+}
@@ -0,0 +1,176 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type Session struct {
+	ID         int64     `json:"id"`
+	MemberID   int64     `json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Expiry     time.Time `json:"expiry"`
+	Current    bool      `json:"current"`
+}
+
+type SessionModel struct {
+	DB *sql.DB
+}
+
+func (m SessionModel) Insert(session *Session) error {
+	query := `
+		INSERT INTO sessions (member_id, user_agent, ip_address, expiry)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, last_used_at
+	`
+
+	args := []interface{}{session.MemberID, session.UserAgent, session.IPAddress, session.Expiry}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt)
+}
+
+func (m SessionModel) IsActive(id, memberID int64) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM sessions
+			WHERE id = $1 AND member_id = $2 AND expiry > NOW()
+		)
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var active bool
+
+	err := m.DB.QueryRowContext(ctx, query, id, memberID).Scan(&active)
+	return active, err
+}
+
+func (m SessionModel) Touch(id, memberID int64, expiry time.Time) (*Session, error) {
+	query := `
+		UPDATE sessions
+		SET last_used_at = NOW(), expiry = $3
+		WHERE id = $1 AND member_id = $2 AND expiry > NOW()
+		RETURNING id, member_id, user_agent, ip_address, created_at, last_used_at, expiry
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var session Session
+
+	err := m.DB.QueryRowContext(ctx, query, id, memberID, expiry).Scan(
+		&session.ID,
+		&session.MemberID,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.Expiry,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &session, nil
+}
+
+func (m SessionModel) GetAllForMember(memberID int64) ([]*Session, error) {
+	query := `
+		SELECT id, member_id, user_agent, ip_address, created_at, last_used_at, expiry
+		FROM sessions
+		WHERE member_id = $1 AND expiry > NOW()
+		ORDER BY last_used_at DESC, id DESC
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, memberID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+
+	for rows.Next() {
+		var session Session
+
+		err := rows.Scan(
+			&session.ID,
+			&session.MemberID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.Expiry,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (m SessionModel) Delete(id, memberID int64) error {
+	query := `
+		DELETE FROM sessions
+		WHERE id = $1 AND member_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, memberID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m SessionModel) DeleteAllForMember(memberID, exceptID int64) error {
+	query := `
+		DELETE FROM sessions
+		WHERE member_id = $1 AND id <> $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, memberID, exceptID)
+	return err
+}
@@ -0,0 +1,312 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+type VolumeStats struct {
+	Workouts    int     `json:"workouts"`
+	Sets        int     `json:"sets"`
+	Repetitions int     `json:"repetitions"`
+	Tonnage     float64 `json:"tonnage"`
+}
+
+type PeriodStats struct {
+	Period time.Time `json:"period"`
+	VolumeStats
+}
+
+type CategoryStats struct {
+	Category string `json:"category"`
+	VolumeStats
+}
+
+type ExerciseStats struct {
+	ExerciseID   int64  `json:"exercise_id"`
+	ExerciseName string `json:"exercise_name"`
+	VolumeStats
+}
+
+type StatsSummary struct {
+	VolumeStats
+	AverageSets     float64    `json:"average_sets_per_workout"`
+	AverageTonnage  float64    `json:"average_tonnage_per_workout"`
+	WorkoutsPerWeek float64    `json:"workouts_per_week"`
+	FirstWorkout    *time.Time `json:"first_workout,omitempty"`
+	LastWorkout     *time.Time `json:"last_workout,omitempty"`
+}
+
+type Stats struct {
+	From       *time.Time       `json:"from,omitempty"`
+	To         *time.Time       `json:"to,omitempty"`
+	Period     string           `json:"period"`
+	WeightUnit string           `json:"weight_unit,omitempty"`
+	Summary    StatsSummary     `json:"summary"`
+	Periods    []*PeriodStats   `json:"periods"`
+	Categories []*CategoryStats `json:"categories"`
+	Exercises  []*ExerciseStats `json:"exercises"`
+}
+
+func (s *Stats) ConvertWeights(unit string) {
+	s.WeightUnit = unit
+	s.Summary.Tonnage = FromKilograms(s.Summary.Tonnage, unit)
+	s.Summary.AverageTonnage = FromKilograms(s.Summary.AverageTonnage, unit)
+
+	for _, period := range s.Periods {
+		period.Tonnage = FromKilograms(period.Tonnage, unit)
+	}
+	for _, category := range s.Categories {
+		category.Tonnage = FromKilograms(category.Tonnage, unit)
+	}
+	for _, exercise := range s.Exercises {
+		exercise.Tonnage = FromKilograms(exercise.Tonnage, unit)
+	}
+}
+
+type ExerciseProgress struct {
+	WorkoutID    int64     `json:"workout_id"`
+	Date         time.Time `json:"date"`
+	TopSetWeight float64   `json:"top_set_weight"`
+	TopSetReps   int       `json:"top_set_repetitions"`
+	Estimated1RM float64   `json:"estimated_1rm"`
+	Sets         int       `json:"sets"`
+	Tonnage      float64   `json:"tonnage"`
+}
+
+func (p *ExerciseProgress) ConvertWeights(unit string) {
+	p.TopSetWeight = FromKilograms(p.TopSetWeight, unit)
+	p.Estimated1RM = FromKilograms(p.Estimated1RM, unit)
+	p.Tonnage = FromKilograms(p.Tonnage, unit)
+}
+
+func ValidatePeriod(v *validator.Validator, period string) {
+	v.Check(validator.PermittedValue(period, PeriodWeek, PeriodMonth), "period", "must be either week or month")
+}
+
+type StatsModel struct {
+	DB *sql.DB
+}
+
+const statsWorkoutsFilter = `
+		FROM workouts w
+		JOIN workout_details wd
+		ON wd.workout_id = w.id
+		JOIN exercises e
+		ON e.id = wd.exercise_id
+		WHERE w.member_id = $1
+		AND NOT wd.planned
+		AND ($2::timestamp IS NULL OR w.date >= $2)
+		AND ($3::timestamp IS NULL OR w.date < $3)
+`
+
+const statsVolumeColumns = `
+		COUNT(DISTINCT w.id), COUNT(wd.id), COALESCE(SUM(wd.repetitions), 0), COALESCE(SUM(wd.weight * wd.repetitions), 0)
+`
+
+func (m StatsModel) Get(memberID int64, workoutFilters WorkoutFilters, period string) (*Stats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	args := []interface{}{memberID, workoutFilters.From, workoutFilters.to()}
+
+	stats := &Stats{
+		From:       workoutFilters.From,
+		To:         workoutFilters.To,
+		Period:     period,
+		Periods:    []*PeriodStats{},
+		Categories: []*CategoryStats{},
+		Exercises:  []*ExerciseStats{},
+	}
+
+	summaryQuery := `SELECT ` + statsVolumeColumns + `, MIN(w.date), MAX(w.date)` + statsWorkoutsFilter
+
+	err := m.DB.QueryRowContext(ctx, summaryQuery, args...).Scan(
+		&stats.Summary.Workouts,
+		&stats.Summary.Sets,
+		&stats.Summary.Repetitions,
+		&stats.Summary.Tonnage,
+		&stats.Summary.FirstWorkout,
+		&stats.Summary.LastWorkout,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Summary.Workouts > 0 {
+		workouts := float64(stats.Summary.Workouts)
+		stats.Summary.AverageSets = math.Round(float64(stats.Summary.Sets)/workouts*100) / 100
+		stats.Summary.AverageTonnage = stats.Summary.Tonnage / workouts
+		stats.Summary.WorkoutsPerWeek = workoutsPerWeek(stats)
+	}
+
+	periodsQuery := `SELECT date_trunc($4, w.date) AS period, ` + statsVolumeColumns + statsWorkoutsFilter + `
+		GROUP BY period
+		ORDER BY period`
+
+	rows, err := m.DB.QueryContext(ctx, periodsQuery, append(args, period)...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var p PeriodStats
+
+		err := rows.Scan(&p.Period, &p.Workouts, &p.Sets, &p.Repetitions, &p.Tonnage)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		stats.Periods = append(stats.Periods, &p)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	categoriesQuery := `SELECT e.category, ` + statsVolumeColumns + statsWorkoutsFilter + `
+		GROUP BY e.category
+		ORDER BY e.category`
+
+	rows, err = m.DB.QueryContext(ctx, categoriesQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var c CategoryStats
+
+		err := rows.Scan(&c.Category, &c.Workouts, &c.Sets, &c.Repetitions, &c.Tonnage)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		stats.Categories = append(stats.Categories, &c)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	exercisesQuery := `SELECT e.id, e.name, ` + statsVolumeColumns + statsWorkoutsFilter + `
+		GROUP BY e.id, e.name
+		ORDER BY e.name, e.id`
+
+	rows, err = m.DB.QueryContext(ctx, exercisesQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e ExerciseStats
+
+		err := rows.Scan(&e.ExerciseID, &e.ExerciseName, &e.Workouts, &e.Sets, &e.Repetitions, &e.Tonnage)
+		if err != nil {
+			return nil, err
+		}
+
+		stats.Exercises = append(stats.Exercises, &e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func workoutsPerWeek(stats *Stats) float64 {
+	from := stats.Summary.FirstWorkout
+	if stats.From != nil {
+		from = stats.From
+	}
+
+	to := stats.Summary.LastWorkout
+	if stats.To != nil {
+		to = stats.To
+	}
+
+	weeks := 1.0
+	if from != nil && to != nil {
+		weeks = math.Max(weeks, (to.Sub(*from).Hours()/24+1)/7)
+	}
+
+	return math.Round(float64(stats.Summary.Workouts)/weeks*100) / 100
+}
+
+func (m StatsModel) GetExerciseProgress(memberID, exerciseID int64, workoutFilters WorkoutFilters, formula string) ([]*ExerciseProgress, error) {
+	query := `
+		SELECT workout_id, date, weight, repetitions, estimated_1rm, sets, tonnage
+		FROM (
+			SELECT DISTINCT ON (w.id)
+				w.id AS workout_id,
+				w.date,
+				wd.weight,
+				wd.repetitions,
+				MAX(CASE
+					WHEN wd.repetitions <= 1 THEN wd.weight
+					WHEN $5 = 'brzycki' THEN wd.weight * 36 / (37 - LEAST(wd.repetitions, 36))
+					ELSE wd.weight * (1 + wd.repetitions / 30.0)
+				END) OVER (PARTITION BY w.id) AS estimated_1rm,
+				COUNT(*) OVER (PARTITION BY w.id) AS sets,
+				SUM(wd.weight * wd.repetitions) OVER (PARTITION BY w.id) AS tonnage
+			FROM workouts w
+			JOIN workout_details wd
+			ON wd.workout_id = w.id
+			WHERE w.member_id = $1
+			AND wd.exercise_id = $2
+			AND NOT wd.planned
+			AND ($3::timestamp IS NULL OR w.date >= $3)
+			AND ($4::timestamp IS NULL OR w.date < $4)
+			AND wd.set_type <> 'warm_up'
+			AND wd.weight > 0
+			AND wd.repetitions > 0
+			ORDER BY w.id, wd.weight DESC, wd.repetitions DESC
+		) series
+		ORDER BY date, workout_id
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, memberID, exerciseID, workoutFilters.From, workoutFilters.to(), formula)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []*ExerciseProgress{}
+
+	for rows.Next() {
+		var p ExerciseProgress
+
+		err := rows.Scan(&p.WorkoutID, &p.Date, &p.TopSetWeight, &p.TopSetReps, &p.Estimated1RM, &p.Sets, &p.Tonnage)
+		if err != nil {
+			return nil, err
+		}
+
+		series = append(series, &p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
@@ -6,7 +6,18 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/base32"
+	"errors"
 	"time"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+const (
+	ScopeActivation    = "activation"
+	ScopeCalendar      = "calendar"
+	ScopePasswordReset = "password-reset"
+	ScopeEmailChange   = "email-change"
+	ScopeRefresh       = "refresh"
 )
 
 type Token struct {
@@ -15,6 +26,7 @@ type Token struct {
 	MemberID  int64
 	Expiry    time.Time
 	Scope     string
+	SessionID *int64
 }
 
 func generateToken(memberID int64, ttl time.Duration, scope string) (*Token, error) {
@@ -39,6 +51,11 @@ func generateToken(memberID int64, ttl time.Duration, scope string) (*Token, err
 	return token, nil
 }
 
+func ValidateTokenPlaintext(v *validator.Validator, tokenPlaintext string) {
+	v.Check(tokenPlaintext != "", "token", "must be provided")
+	v.Check(len(tokenPlaintext) == 26, "token", "must be 26 bytes long")
+}
+
 type TokenModel struct {
 	DB *sql.DB
 }
@@ -53,13 +70,60 @@ func (m TokenModel) New(memberID int64, ttl time.Duration, scope string) (*Token
 	return token, err
 }
 
+func (m TokenModel) NewRefresh(memberID, sessionID int64, ttl time.Duration) (*Token, error) {
+	token, err := generateToken(memberID, ttl, ScopeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	token.SessionID = &sessionID
+
+	err = m.Insert(token)
+	return token, err
+}
+
+func (m TokenModel) ConsumeRefresh(tokenPlaintext string) (*Token, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+		DELETE FROM tokens
+		WHERE hash = $1 AND scope = $2 AND expiry > $3
+		RETURNING member_id, expiry, session_id
+	`
+
+	token := &Token{
+		Plaintext: tokenPlaintext,
+		Hash:      tokenHash[:],
+		Scope:     ScopeRefresh,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, token.Hash, ScopeRefresh, time.Now()).Scan(&token.MemberID, &token.Expiry, &token.SessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if token.SessionID == nil {
+		return nil, ErrRecordNotFound
+	}
+
+	return token, nil
+}
+
 func (m TokenModel) Insert(token *Token) error {
 	query := `
-		INSERT INTO tokens (hash, member_id, expiry, scope)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO tokens (hash, member_id, expiry, scope, session_id)
+		VALUES ($1, $2, $3, $4, $5)
 	`
 
-	args := []interface{}{token.Hash, token.MemberID, token.Expiry, token.Scope}
+	args := []interface{}{token.Hash, token.MemberID, token.Expiry, token.Scope, token.SessionID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
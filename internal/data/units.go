@@ -0,0 +1,90 @@
+package data
+
+import (
+	"fmt"
+	"math"
+
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
+)
+
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+
+	UnitKilograms = "kg"
+	UnitPounds    = "lb"
+
+	UnitCentimeters = "cm"
+	UnitInches      = "in"
+)
+
+const (
+	poundsPerKilogram   = 2.20462262185
+	centimetersPerInch  = 2.54
+	inchesPerFoot       = 12
+	weightDecimalPlaces = 2
+)
+
+func ValidateUnits(v *validator.Validator, units string) {
+	v.Check(validator.PermittedValue(units, UnitsMetric, UnitsImperial), "preferred_units", "must be either metric or imperial")
+}
+
+func ValidateWeightUnit(v *validator.Validator, unit string) {
+	v.Check(validator.PermittedValue(unit, UnitKilograms, UnitPounds), "unit", "must be either kg or lb")
+}
+
+func WeightUnit(units string) string {
+	if units == UnitsImperial {
+		return UnitPounds
+	}
+	return UnitKilograms
+}
+
+func HeightUnit(units string) string {
+	if units == UnitsImperial {
+		return UnitInches
+	}
+	return UnitCentimeters
+}
+
+func ToKilograms(weight float64, unit string) float64 {
+	if unit == UnitPounds {
+		return weight / poundsPerKilogram
+	}
+	return weight
+}
+
+func FromKilograms(kilograms float64, unit string) float64 {
+	if unit == UnitPounds {
+		kilograms = kilograms * poundsPerKilogram
+	}
+
+	scale := math.Pow(10, weightDecimalPlaces)
+	return math.Round(kilograms*scale) / scale
+}
+
+func ToCentimeters(height float64, unit string) float64 {
+	if unit == UnitInches {
+		return height * centimetersPerInch
+	}
+	return height
+}
+
+func FromCentimeters(centimeters float64, unit string) float64 {
+	if unit == UnitInches {
+		return math.Round(centimeters / centimetersPerInch)
+	}
+	return centimeters
+}
+
+func FromCentimetersPrecise(centimeters float64, unit string) float64 {
+	if unit == UnitInches {
+		centimeters = centimeters / centimetersPerInch
+	}
+	return math.Round(centimeters*10) / 10
+}
+
+func FeetAndInches(centimeters float64) string {
+	totalInches := int64(math.Round(centimeters / centimetersPerInch))
+	return fmt.Sprintf("%d ft %d in", totalInches/inchesPerFoot, totalInches%inchesPerFoot)
+}
@@ -3,43 +3,294 @@ package data
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/lib/pq"
+	"workout-tracker-go.ilijakrilovic.com/internal/validator"
 )
 
 type Workout struct {
-	ID       int64            `json:"id"`
-	MemberID int64            `json:"member_id"`
-	Date     time.Time        `json:"date"`
-	Details  []*WorkoutDetail `json:"details"`
-	Version  int              `json:"-"`
+	ID         int64             `json:"id"`
+	MemberID   int64             `json:"member_id"`
+	Date       time.Time         `json:"date"`
+	Notes      string            `json:"notes"`
+	WeightUnit string            `json:"weight_unit,omitempty"`
+	ProgramID  *int64            `json:"program_id,omitempty"`
+	Details    []*WorkoutDetail  `json:"details"`
+	NewRecords []*PersonalRecord `json:"new_records,omitempty"`
+	Version    int               `json:"version"`
+}
+
+func (w *Workout) ConvertWeights(unit string) {
+	w.WeightUnit = unit
+	for _, detail := range w.Details {
+		detail.Weight = FromKilograms(detail.Weight, unit)
+	}
+	for _, record := range w.NewRecords {
+		record.ConvertWeights(unit)
+	}
 }
 
 type WorkoutDetail struct {
-	ID          int64   `json:"-"`
-	WorkoutID   int64   `json:"workout_id"`
-	ExerciseID  int64   `json:"exercise_id"`
-	Set         int     `json:"set"`
-	Repetitions int     `json:"repetitions"`
-	Weight      float64 `json:"weight"`
+	ID              int64    `json:"id"`
+	WorkoutID       int64    `json:"workout_id"`
+	ExerciseID      int64    `json:"exercise_id"`
+	Position        int      `json:"position"`
+	Set             int      `json:"set"`
+	Repetitions     int      `json:"repetitions"`
+	Weight          float64  `json:"weight"`
+	DurationSeconds *int     `json:"duration_seconds,omitempty"`
+	DistanceMeters  *float64 `json:"distance_meters,omitempty"`
+	HeartRate       *int     `json:"heart_rate,omitempty"`
+	Calories        *int     `json:"calories,omitempty"`
+	RPE             *float64 `json:"rpe,omitempty"`
+	RIR             *int     `json:"rir,omitempty"`
+	Tempo           string   `json:"tempo,omitempty"`
+	RestSeconds     *int     `json:"rest_seconds,omitempty"`
+	SetType         string   `json:"set_type"`
+	Notes           string   `json:"notes,omitempty"`
+	Planned         bool     `json:"planned,omitempty"`
+}
+
+var workoutDetailColumns = []string{
+	"exercise_id",
+	"set",
+	"repetitions",
+	"weight",
+	"duration_seconds",
+	"distance_meters",
+	"heart_rate",
+	"calories",
+	"rpe",
+	"rir",
+	"tempo",
+	"rest_seconds",
+	"set_type",
+	"notes",
+	"planned",
+}
+
+func (d *WorkoutDetail) columnValues() []interface{} {
+	return []interface{}{
+		d.ExerciseID,
+		d.Set,
+		d.Repetitions,
+		d.Weight,
+		d.DurationSeconds,
+		d.DistanceMeters,
+		d.HeartRate,
+		d.Calories,
+		d.RPE,
+		d.RIR,
+		d.Tempo,
+		d.RestSeconds,
+		d.SetType,
+		d.Notes,
+		d.Planned,
+	}
 }
 
 type WorkoutResponse struct {
-	ID       int64                    `json:"id"`
-	MemberID int64                    `json:"-"`
-	Date     time.Time                `json:"date"`
-	Details  []*WorkoutDetailResponse `json:"details"`
-	Version  int                      `json:"-"`
+	ID         int64                    `json:"id"`
+	MemberID   int64                    `json:"-"`
+	Date       time.Time                `json:"date"`
+	Notes      string                   `json:"notes"`
+	WeightUnit string                   `json:"weight_unit,omitempty"`
+	Details    []*WorkoutDetailResponse `json:"details"`
+	Version    int                      `json:"version"`
+}
+
+func (w *WorkoutResponse) ConvertWeights(unit string) {
+	w.WeightUnit = unit
+	for _, detail := range w.Details {
+		detail.Weight = FromKilograms(detail.Weight, unit)
+	}
 }
 
 type WorkoutDetailResponse struct {
-	ID          int64    `json:"-"`
-	WorkoutID   int64    `json:"-"`
-	Exercise    Exercise `json:"exercise"`
-	Set         int      `json:"set"`
-	Repetitions int      `json:"repetitions"`
-	Weight      float64  `json:"weight"`
+	ID              int64    `json:"id"`
+	WorkoutID       int64    `json:"-"`
+	Exercise        Exercise `json:"exercise"`
+	Position        int      `json:"position"`
+	Set             int      `json:"set"`
+	Repetitions     int      `json:"repetitions"`
+	Weight          float64  `json:"weight"`
+	DurationSeconds *int     `json:"duration_seconds,omitempty"`
+	DistanceMeters  *float64 `json:"distance_meters,omitempty"`
+	HeartRate       *int     `json:"heart_rate,omitempty"`
+	Calories        *int     `json:"calories,omitempty"`
+	RPE             *float64 `json:"rpe,omitempty"`
+	RIR             *int     `json:"rir,omitempty"`
+	Tempo           string   `json:"tempo,omitempty"`
+	RestSeconds     *int     `json:"rest_seconds,omitempty"`
+	SetType         string   `json:"set_type"`
+	Notes           string   `json:"notes,omitempty"`
+	Planned         bool     `json:"planned,omitempty"`
+}
+
+func (d *WorkoutDetailResponse) ToDetail() *WorkoutDetail {
+	return &WorkoutDetail{
+		ID:              d.ID,
+		WorkoutID:       d.WorkoutID,
+		ExerciseID:      d.Exercise.ID,
+		Position:        d.Position,
+		Set:             d.Set,
+		Repetitions:     d.Repetitions,
+		Weight:          d.Weight,
+		DurationSeconds: d.DurationSeconds,
+		DistanceMeters:  d.DistanceMeters,
+		HeartRate:       d.HeartRate,
+		Calories:        d.Calories,
+		RPE:             d.RPE,
+		RIR:             d.RIR,
+		Tempo:           d.Tempo,
+		RestSeconds:     d.RestSeconds,
+		SetType:         d.SetType,
+		Notes:           d.Notes,
+		Planned:         d.Planned,
+	}
+}
+
+type WorkoutFilters struct {
+	From       *time.Time
+	To         *time.Time
+	ExerciseID int64
+	Category   string
+	MinWeight  float64
+}
+
+func (f WorkoutFilters) to() *time.Time {
+	if f.To == nil {
+		return nil
+	}
+
+	to := f.To.AddDate(0, 0, 1)
+	return &to
+}
+
+const workoutFiltersClause = `
+		WHERE w.member_id = $1
+		AND ($2::timestamp IS NULL OR w.date >= $2)
+		AND ($3::timestamp IS NULL OR w.date < $3)
+		AND (($4::bigint = 0 AND $5::text = '' AND $6::float8 = 0) OR EXISTS (
+			SELECT 1
+			FROM workout_details wd
+			JOIN exercises e
+			ON e.id = wd.exercise_id
+			WHERE wd.workout_id = w.id
+			AND ($4::bigint = 0 OR wd.exercise_id = $4::bigint)
+			AND ($5::text = '' OR e.category = $5::text)
+			AND wd.weight >= $6::float8
+		))`
+
+func (f WorkoutFilters) args(memberID int64) []interface{} {
+	return []interface{}{
+		memberID,
+		f.From,
+		f.to(),
+		f.ExerciseID,
+		f.Category,
+		f.MinWeight,
+	}
+}
+
+func ValidateWorkoutFilters(v *validator.Validator, f WorkoutFilters) {
+	v.Check(f.ExerciseID >= 0, "exercise_id", "must not be negative")
+	v.Check(f.MinWeight >= 0, "min_weight", "must not be negative")
+
+	if f.Category != "" {
+		v.Check(allowedCategories[f.Category], "category", "invalid category")
+	}
+
+	if f.From != nil && f.To != nil {
+		v.Check(!f.To.Before(*f.From), "to", "must not be before from")
+	}
+}
+
+const (
+	SetTypeWarmUp  = "warm_up"
+	SetTypeWorking = "working"
+	SetTypeDropSet = "drop_set"
+	SetTypeFailure = "failure"
+	SetTypeAMRAP   = "amrap"
+)
+
+var allowedSetTypes = map[string]bool{
+	SetTypeWarmUp:  true,
+	SetTypeWorking: true,
+	SetTypeDropSet: true,
+	SetTypeFailure: true,
+	SetTypeAMRAP:   true,
+}
+
+var TempoRX = regexp.MustCompile(`^[0-9X](-?[0-9X]){3}$`)
+
+func ValidateWorkoutDetail(v *validator.Validator, prefix string, detail *WorkoutDetail, measurementType string) {
+	validateMeasurement(v, prefix, detail, measurementType)
+
+	v.Check(allowedSetTypes[detail.SetType], prefix+"set_type", "must be one of warm_up, working, drop_set, failure or amrap")
+
+	if detail.RPE != nil {
+		v.Check(*detail.RPE >= 6 && *detail.RPE <= 10, prefix+"rpe", "must be between 6 and 10")
+		v.Check(math.Mod(*detail.RPE*2, 1) == 0, prefix+"rpe", "must be in steps of 0.5")
+	}
+
+	if detail.RIR != nil {
+		v.Check(*detail.RIR >= 0 && *detail.RIR <= 10, prefix+"rir", "must be between 0 and 10")
+	}
+
+	if detail.Tempo != "" {
+		v.Check(validator.Matches(detail.Tempo, TempoRX), prefix+"tempo", "must be four digits or X, e.g. 3-1-2-0")
+	}
+
+	if detail.RestSeconds != nil {
+		v.Check(*detail.RestSeconds >= 0 && *detail.RestSeconds <= 3600, prefix+"rest_seconds", "must be between 0 and 3600")
+	}
+
+	v.Check(len(detail.Notes) <= 500, prefix+"notes", "must not be more than 500 bytes long")
+}
+
+func validateMeasurement(v *validator.Validator, prefix string, detail *WorkoutDetail, measurementType string) {
+	v.Check(detail.Set > 0, prefix+"set", "must be greater than zero")
+	v.Check(detail.Repetitions >= 0, prefix+"repetitions", "must not be negative")
+	v.Check(detail.Weight >= 0, prefix+"weight", "must not be negative")
+
+	switch measurementType {
+	case MeasurementWeightReps, MeasurementBodyweightReps:
+		v.Check(detail.Repetitions > 0, prefix+"repetitions", "must be provided for this exercise")
+	case MeasurementTime:
+		v.Check(detail.DurationSeconds != nil, prefix+"duration_seconds", "must be provided for this exercise")
+	case MeasurementDistance:
+		v.Check(detail.DistanceMeters != nil, prefix+"distance_meters", "must be provided for this exercise")
+	}
+
+	if detail.DurationSeconds != nil {
+		v.Check(*detail.DurationSeconds > 0, prefix+"duration_seconds", "must be greater than zero")
+		v.Check(*detail.DurationSeconds <= 86_400, prefix+"duration_seconds", "must not be more than 24 hours")
+	}
+
+	if detail.DistanceMeters != nil {
+		v.Check(*detail.DistanceMeters > 0, prefix+"distance_meters", "must be greater than zero")
+		v.Check(*detail.DistanceMeters <= 1_000_000, prefix+"distance_meters", "must not be more than 1000 km")
+	}
+
+	if detail.HeartRate != nil {
+		v.Check(*detail.HeartRate >= 20 && *detail.HeartRate <= 250, prefix+"heart_rate", "must be between 20 and 250")
+	}
+
+	if detail.Calories != nil {
+		v.Check(*detail.Calories >= 0 && *detail.Calories <= 10_000, prefix+"calories", "must be between 0 and 10000")
+	}
+}
+
+func ValidateWorkout(v *validator.Validator, date time.Time, notes string) {
+	v.Check(!date.IsZero(), "date", "must be provided")
+	v.Check(len(notes) <= 1000, "notes", "must not be more than 1000 bytes long")
 }
 
 type WorkoutModel struct {
@@ -53,135 +304,777 @@ func (w WorkoutModel) Insert(workout *Workout) error {
 		return err
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err = insertWorkout(ctx, tx, workout)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func insertWorkout(ctx context.Context, tx *sql.Tx, workout *Workout) error {
+	err := insertWorkoutRows(ctx, tx, workout)
+	if err != nil {
+		return err
+	}
+
+	workout.NewRecords, err = detectPersonalRecords(ctx, tx, workout)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func insertWorkoutRows(ctx context.Context, tx *sql.Tx, workout *Workout) error {
 	workoutQuery := `
-		INSERT INTO workouts (member_id, date)
-		VALUES ($1, $2)
-		RETURNING id
+		INSERT INTO workouts (member_id, date, notes, program_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, version
 	`
 
-	args := []interface{}{workout.MemberID, workout.Date}
+	args := []interface{}{workout.MemberID, workout.Date, workout.Notes, workout.ProgramID}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	err := tx.QueryRowContext(ctx, workoutQuery, args...).Scan(&workout.ID, &workout.Version)
+	if err != nil {
+		return err
+	}
 
-	err = tx.QueryRowContext(ctx, workoutQuery, args...).Scan(&workout.ID)
+	exerciseIDs := []int64{}
+	for _, detail := range workout.Details {
+		exerciseIDs = append(exerciseIDs, detail.ExerciseID)
+	}
+
+	err = checkExercises(ctx, tx, workout.MemberID, exerciseIDs)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
 	values := []string{}
 	args = []interface{}{}
-	argCounter := 1
 
-	for _, detail := range workout.Details {
+	for i, detail := range workout.Details {
 		detail.WorkoutID = workout.ID
-		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)", argCounter, argCounter+1, argCounter+2, argCounter+3, argCounter+4))
-		args = append(args, detail.WorkoutID, detail.ExerciseID, detail.Set, detail.Repetitions, detail.Weight)
-		argCounter += 5
-	}
+		detail.Position = i + 1
 
-	fmt.Println(values)
+		rowArgs := append([]interface{}{detail.WorkoutID, detail.Position}, detail.columnValues()...)
+		values = append(values, "("+placeholders(len(args)+1, len(rowArgs))+")")
+		args = append(args, rowArgs...)
+	}
 
 	detailsQuery := `
-		INSERT INTO workout_details (workout_id, exercise_id, set, repetitions, weight)
-		VALUES ` + strings.Join(values, ", ")
+		INSERT INTO workout_details (workout_id, position, ` + strings.Join(workoutDetailColumns, ", ") + `)
+		VALUES ` + strings.Join(values, ", ") + `
+		RETURNING id`
 
-	_, err = tx.ExecContext(ctx, detailsQuery, args...)
+	rows, err := tx.QueryContext(ctx, detailsQuery, args...)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	err = tx.Commit()
+	for i := 0; rows.Next(); i++ {
+		err = rows.Scan(&workout.Details[i].ID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+	}
+
+	rows.Close()
+
+	return rows.Err()
+}
+
+func (w WorkoutModel) GetByMemberID(memberID int64, workoutFilters WorkoutFilters, filters Filters) ([]*WorkoutResponse, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), w.id, w.member_id, w.date, w.notes, w.version
+		FROM workouts w`+workoutFiltersClause+`
+		ORDER BY w.%s %s, w.id ASC
+		LIMIT $7 OFFSET $8`, filters.sortColumn(), filters.sortDirection())
+
+	args := append(workoutFilters.args(memberID), filters.limit(), filters.offset())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := w.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return nil, Metadata{}, err
 	}
+	defer rows.Close()
 
-	return nil
+	totalRecords := 0
+	workouts := []*WorkoutResponse{}
+	workoutsByID := make(map[int64]*WorkoutResponse)
+	workoutIDs := []int64{}
+
+	for rows.Next() {
+		var workout WorkoutResponse
+
+		err := rows.Scan(
+			&totalRecords,
+			&workout.ID,
+			&workout.MemberID,
+			&workout.Date,
+			&workout.Notes,
+			&workout.Version,
+		)
+
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+
+		workout.Details = []*WorkoutDetailResponse{}
+		workouts = append(workouts, &workout)
+		workoutsByID[workout.ID] = &workout
+		workoutIDs = append(workoutIDs, workout.ID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	if len(workoutIDs) == 0 {
+		return workouts, metadata, nil
+	}
+
+	details, err := w.getDetails(ctx, workoutIDs)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	for _, detail := range details {
+		workout := workoutsByID[detail.WorkoutID]
+		workout.Details = append(workout.Details, detail)
+	}
+
+	return workouts, metadata, nil
+}
+
+type WorkoutExportRow struct {
+	Date         time.Time `json:"date"`
+	WorkoutNotes string    `json:"workout_notes,omitempty"`
+	ExerciseName string    `json:"exercise_name"`
+	Category     string    `json:"category"`
+	WeightUnit   string    `json:"weight_unit"`
+	WorkoutDetail
 }
 
-func (w WorkoutModel) GetByMemberID(memberID int64) ([]*WorkoutResponse, error) {
+func (w WorkoutModel) Export(ctx context.Context, memberID int64, workoutFilters WorkoutFilters, fn func(*WorkoutExportRow) error) error {
 	query := `
-		SELECT 	w.id, w.date, wd.set, wd.repetitions, wd.weight, e.name, e.category, e.description 
-		FROM workouts w 
-		JOIN workout_details AS wd 
-		ON w.id = wd.workout_id
+		SELECT w.date, w.notes, e.name, e.category, wd.id, wd.workout_id, wd.position,
+			wd.` + strings.Join(workoutDetailColumns, ", wd.") + `
+		FROM workouts w
+		JOIN workout_details wd
+		ON wd.workout_id = w.id
 		JOIN exercises e
-		ON e.id = wd.exercise_id
-		WHERE w.member_id = $1;
+		ON e.id = wd.exercise_id` + workoutFiltersClause + `
+		ORDER BY w.date, w.id, wd.position
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	rows, err := w.DB.QueryContext(ctx, query, workoutFilters.args(memberID)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var row WorkoutExportRow
+
+	for rows.Next() {
+		row = WorkoutExportRow{}
+
+		err := rows.Scan(
+			&row.Date,
+			&row.WorkoutNotes,
+			&row.ExerciseName,
+			&row.Category,
+			&row.ID,
+			&row.WorkoutID,
+			&row.Position,
+			&row.ExerciseID,
+			&row.Set,
+			&row.Repetitions,
+			&row.Weight,
+			&row.DurationSeconds,
+			&row.DistanceMeters,
+			&row.HeartRate,
+			&row.Calories,
+			&row.RPE,
+			&row.RIR,
+			&row.Tempo,
+			&row.RestSeconds,
+			&row.SetType,
+			&row.Notes,
+			&row.Planned,
+		)
+
+		if err != nil {
+			return err
+		}
+
+		err = fn(&row)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (w WorkoutModel) GetInRange(memberID int64, from, to time.Time) ([]*WorkoutResponse, error) {
+	query := `
+		SELECT id, member_id, date, notes, version
+		FROM workouts
+		WHERE member_id = $1 AND date >= $2 AND date < $3
+		ORDER BY date, id
 	`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := w.DB.QueryContext(ctx, query, memberID)
+	rows, err := w.DB.QueryContext(ctx, query, memberID, from, to)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	workouts := make(map[int64]*WorkoutResponse)
+	workouts := []*WorkoutResponse{}
+	workoutsByID := make(map[int64]*WorkoutResponse)
+	workoutIDs := []int64{}
 
 	for rows.Next() {
 		var workout WorkoutResponse
-		var detail WorkoutDetailResponse
 
 		err := rows.Scan(
 			&workout.ID,
+			&workout.MemberID,
 			&workout.Date,
-			&detail.Set,
-			&detail.Repetitions,
-			&detail.Weight,
-			&detail.Exercise.Name,
-			&detail.Exercise.Category,
-			&detail.Exercise.Description,
+			&workout.Notes,
+			&workout.Version,
 		)
 
 		if err != nil {
 			return nil, err
 		}
 
-		if _, ok := workouts[workout.ID]; !ok {
-			workout.Details = append(workout.Details, &detail)
-			workouts[workout.ID] = &workout
-		} else {
-			updateWorkout := workouts[workout.ID]
-			updateWorkout.Details = append(updateWorkout.Details, &detail)
-		}
+		workout.Details = []*WorkoutDetailResponse{}
+		workouts = append(workouts, &workout)
+		workoutsByID[workout.ID] = &workout
+		workoutIDs = append(workoutIDs, workout.ID)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	workoutsSlice := make([]*WorkoutResponse, 0, len(workouts))
-	for _, workout := range workouts {
-		fmt.Println(workout.ID)
-		workoutsSlice = append(workoutsSlice, workout)
+	if len(workoutIDs) == 0 {
+		return workouts, nil
 	}
 
-	return workoutsSlice, nil
+	details, err := w.getDetails(ctx, workoutIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, detail := range details {
+		workout := workoutsByID[detail.WorkoutID]
+		workout.Details = append(workout.Details, detail)
+	}
+
+	return workouts, nil
 }
 
-func (w WorkoutModel) Delete(id int64) error {
+func (w WorkoutModel) Delete(workout *WorkoutResponse) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := w.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
 	query := `
+		SELECT DISTINCT wd.exercise_id
+		FROM workout_details wd
+		JOIN workouts w
+		ON w.id = wd.workout_id
+		WHERE w.id = $1 AND w.member_id = $2
+	`
+
+	rows, err := tx.QueryContext(ctx, query, workout.ID, workout.MemberID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	exerciseIDs := []int64{}
+
+	for rows.Next() {
+		var exerciseID int64
+
+		err = rows.Scan(&exerciseID)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return err
+		}
+
+		exerciseIDs = append(exerciseIDs, exerciseID)
+	}
+
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query = `
 		DELETE FROM workouts
-		WHERE id = $1
+		WHERE id = $1 AND member_id = $2 AND version = $3
 	`
-	result, err := w.DB.Exec(query, id)
+
+	result, err := tx.ExecContext(ctx, query, workout.ID, workout.MemberID, workout.Version)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
 	if rowsAffected == 0 {
-		return ErrRecordNotFound
+		tx.Rollback()
+		return ErrEditConflict
+	}
+
+	if len(exerciseIDs) > 0 {
+		_, err = recomputePersonalRecords(ctx, tx, workout.MemberID, exerciseIDs)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (w WorkoutModel) Get(id, memberID int64) (*WorkoutResponse, error) {
+	query := `
+		SELECT id, member_id, date, notes, version
+		FROM workouts
+		WHERE id = $1 AND member_id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var workout WorkoutResponse
+
+	err := w.DB.QueryRowContext(ctx, query, id, memberID).Scan(
+		&workout.ID,
+		&workout.MemberID,
+		&workout.Date,
+		&workout.Notes,
+		&workout.Version,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	workout.Details, err = w.getDetails(ctx, []int64{workout.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &workout, nil
+}
+
+func (w WorkoutModel) Update(workout *WorkoutResponse) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := w.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE workouts w
+		SET date = $1, notes = $2, version = w.version + 1
+		FROM workouts old
+		WHERE w.id = $3 AND w.member_id = $4 AND w.version = $5 AND old.id = w.id
+		RETURNING w.version, old.date
+	`
+
+	args := []interface{}{workout.Date, workout.Notes, workout.ID, workout.MemberID, workout.Version}
+
+	var previousDate time.Time
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&workout.Version, &previousDate)
+	if err != nil {
+		tx.Rollback()
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	exerciseIDs := []int64{}
+	for _, detail := range workout.Details {
+		exerciseIDs = append(exerciseIDs, detail.Exercise.ID)
+	}
+
+	if !previousDate.Equal(workout.Date) && len(exerciseIDs) > 0 {
+		_, err = recomputePersonalRecords(ctx, tx, workout.MemberID, exerciseIDs)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (w WorkoutModel) InsertDetail(workout *WorkoutResponse, detail *WorkoutDetail) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := w.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = bumpWorkoutVersion(ctx, tx, workout)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = checkExercises(ctx, tx, workout.MemberID, []int64{detail.ExerciseID})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := `
+		INSERT INTO workout_details (workout_id, position, ` + strings.Join(workoutDetailColumns, ", ") + `)
+		VALUES ($1, (SELECT COALESCE(MAX(position), 0) + 1 FROM workout_details WHERE workout_id = $1), ` + placeholders(2, len(workoutDetailColumns)) + `)
+		RETURNING id, position
+	`
+
+	detail.WorkoutID = workout.ID
+	args := append([]interface{}{detail.WorkoutID}, detail.columnValues()...)
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&detail.ID, &detail.Position)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = recomputePersonalRecords(ctx, tx, workout.MemberID, []int64{detail.ExerciseID})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (w WorkoutModel) UpdateDetail(workout *WorkoutResponse, detail *WorkoutDetail) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := w.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = bumpWorkoutVersion(ctx, tx, workout)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = checkExercises(ctx, tx, workout.MemberID, []int64{detail.ExerciseID})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var previousExerciseID int64
+
+	query := `
+		SELECT exercise_id
+		FROM workout_details
+		WHERE id = $1 AND workout_id = $2
+		FOR UPDATE
+	`
+
+	err = tx.QueryRowContext(ctx, query, detail.ID, workout.ID).Scan(&previousExerciseID)
+	if err != nil {
+		tx.Rollback()
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	assignments := []string{}
+	for i, column := range workoutDetailColumns {
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", column, i+1))
+	}
+
+	query = fmt.Sprintf(`
+		UPDATE workout_details
+		SET %s
+		WHERE id = $%d AND workout_id = $%d`, strings.Join(assignments, ", "), len(workoutDetailColumns)+1, len(workoutDetailColumns)+2)
+
+	args := append(detail.columnValues(), detail.ID, workout.ID)
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	exerciseIDs := []int64{detail.ExerciseID}
+	if previousExerciseID != detail.ExerciseID {
+		exerciseIDs = append(exerciseIDs, previousExerciseID)
+	}
+
+	_, err = recomputePersonalRecords(ctx, tx, workout.MemberID, exerciseIDs)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (w WorkoutModel) DeleteDetail(workout *WorkoutResponse, detailID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := w.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = bumpWorkoutVersion(ctx, tx, workout)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := `
+		DELETE FROM workout_details
+		WHERE id = $1 AND workout_id = $2
+		RETURNING exercise_id
+	`
+
+	var exerciseID int64
+
+	err = tx.QueryRowContext(ctx, query, detailID, workout.ID).Scan(&exerciseID)
+	if err != nil {
+		tx.Rollback()
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	_, err = recomputePersonalRecords(ctx, tx, workout.MemberID, []int64{exerciseID})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (w WorkoutModel) ReorderDetails(workout *WorkoutResponse, detailIDs []int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := w.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = bumpWorkoutVersion(ctx, tx, workout)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := `
+		UPDATE workout_details
+		SET position = ordered.position
+		FROM unnest($1::bigint[]) WITH ORDINALITY AS ordered(id, position)
+		WHERE workout_details.id = ordered.id AND workout_details.workout_id = $2
+	`
+
+	_, err = tx.ExecContext(ctx, query, pq.Array(detailIDs), workout.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func bumpWorkoutVersion(ctx context.Context, tx *sql.Tx, workout *WorkoutResponse) error {
+	query := `
+		UPDATE workouts
+		SET version = version + 1
+		WHERE id = $1 AND member_id = $2 AND version = $3
+		RETURNING version
+	`
+
+	err := tx.QueryRowContext(ctx, query, workout.ID, workout.MemberID, workout.Version).Scan(&workout.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
 	}
 
 	return nil
 }
+
+func checkExercises(ctx context.Context, tx *sql.Tx, memberID int64, exerciseIDs []int64) error {
+	uniqueIDs := []int64{}
+	seen := make(map[int64]bool)
+
+	for _, id := range exerciseIDs {
+		if !seen[id] {
+			seen[id] = true
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+
+	query := `
+		SELECT COUNT(*)
+		FROM exercises
+		WHERE id = ANY($1)
+		AND (owner_member_id IS NULL OR owner_member_id = $2)
+	`
+
+	var visibleExercises int
+
+	err := tx.QueryRowContext(ctx, query, pq.Array(uniqueIDs), memberID).Scan(&visibleExercises)
+	if err != nil {
+		return err
+	}
+
+	if visibleExercises != len(uniqueIDs) {
+		return ErrInvalidExercise
+	}
+
+	return nil
+}
+
+func (w WorkoutModel) getDetails(ctx context.Context, workoutIDs []int64) ([]*WorkoutDetailResponse, error) {
+	query := `
+		SELECT wd.id, wd.workout_id, wd.position, wd.set, wd.repetitions, wd.weight,
+			wd.duration_seconds, wd.distance_meters, wd.heart_rate, wd.calories,
+			wd.rpe, wd.rir, wd.tempo, wd.rest_seconds, wd.set_type, wd.notes, wd.planned,
+			e.id, e.name, e.category, e.description, e.measurement_type, e.owner_member_id
+		FROM workout_details wd
+		JOIN exercises e
+		ON e.id = wd.exercise_id
+		WHERE wd.workout_id = ANY($1)
+		ORDER BY wd.workout_id, wd.position
+	`
+
+	rows, err := w.DB.QueryContext(ctx, query, pq.Array(workoutIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	details := []*WorkoutDetailResponse{}
+
+	for rows.Next() {
+		var detail WorkoutDetailResponse
+
+		err := rows.Scan(
+			&detail.ID,
+			&detail.WorkoutID,
+			&detail.Position,
+			&detail.Set,
+			&detail.Repetitions,
+			&detail.Weight,
+			&detail.DurationSeconds,
+			&detail.DistanceMeters,
+			&detail.HeartRate,
+			&detail.Calories,
+			&detail.RPE,
+			&detail.RIR,
+			&detail.Tempo,
+			&detail.RestSeconds,
+			&detail.SetType,
+			&detail.Notes,
+			&detail.Planned,
+			&detail.Exercise.ID,
+			&detail.Exercise.Name,
+			&detail.Exercise.Category,
+			&detail.Exercise.Description,
+			&detail.Exercise.MeasurementType,
+			&detail.Exercise.OwnerMemberID,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		details = append(details, &detail)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+func placeholders(start, count int) string {
+	params := make([]string, count)
+	for i := range params {
+		params[i] = fmt.Sprintf("$%d", start+i)
+	}
+
+	return strings.Join(params, ", ")
+}
@@ -0,0 +1,112 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+
+	maxLineOctets = 75
+	dateFormat    = "20060102"
+	timeFormat    = "20060102T150405Z"
+)
+
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+type Event struct {
+	UID         string
+	Stamp       time.Time
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Summary     string
+	Description string
+	Status      string
+}
+
+func Encode(w io.Writer, calendar *Calendar) error {
+	bw := bufio.NewWriter(w)
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:"+calendar.ProdID)
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	writeLine(bw, "METHOD:PUBLISH")
+
+	if calendar.Name != "" {
+		writeLine(bw, "X-WR-CALNAME:"+escapeText(calendar.Name))
+	}
+
+	for _, event := range calendar.Events {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+event.UID)
+		writeLine(bw, "DTSTAMP:"+event.Stamp.UTC().Format(timeFormat))
+
+		if event.AllDay {
+			writeLine(bw, "DTSTART;VALUE=DATE:"+event.Start.Format(dateFormat))
+			writeLine(bw, "DTEND;VALUE=DATE:"+event.End.Format(dateFormat))
+		} else {
+			writeLine(bw, "DTSTART:"+event.Start.UTC().Format(timeFormat))
+			writeLine(bw, "DTEND:"+event.End.UTC().Format(timeFormat))
+		}
+
+		writeLine(bw, "SUMMARY:"+escapeText(event.Summary))
+
+		if event.Description != "" {
+			writeLine(bw, "DESCRIPTION:"+escapeText(event.Description))
+		}
+
+		if event.Status != "" {
+			writeLine(bw, "STATUS:"+event.Status)
+		}
+
+		writeLine(bw, "END:VEVENT")
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+
+		line = line[cut:]
+		limit = maxLineOctets - 1
+	}
+
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
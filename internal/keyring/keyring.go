@@ -0,0 +1,240 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/pascaldekloe/jwt"
+)
+
+const minRSABits = 2048
+
+var ErrNoSigningKey = errors.New("keyring: no signing key or secret configured")
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type Keyring struct {
+	signingID  string
+	rsaKey     *rsa.PrivateKey
+	ed25519Key ed25519.PrivateKey
+	secret     []byte
+	register   jwt.KeyRegister
+	keys       []JWK
+}
+
+func New(signingKeyFile string, verificationKeyFiles []string, secret string) (*Keyring, error) {
+	kr := &Keyring{}
+
+	if signingKeyFile == "" {
+		if secret == "" {
+			return nil, ErrNoSigningKey
+		}
+
+		kr.secret = []byte(secret)
+		kr.register.Secrets = [][]byte{kr.secret}
+		return kr, nil
+	}
+
+	keys, err := loadPEMFile(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) != 1 {
+		return nil, fmt.Errorf("keyring: %s must contain exactly one private key", signingKeyFile)
+	}
+
+	switch key := keys[0].(type) {
+	case *rsa.PrivateKey:
+		kr.rsaKey = key
+	case ed25519.PrivateKey:
+		kr.ed25519Key = key
+	default:
+		return nil, fmt.Errorf("keyring: %s must contain an RSA or Ed25519 private key", signingKeyFile)
+	}
+
+	kr.signingID, err = kr.add(keys[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range verificationKeyFiles {
+		keys, err := loadPEMFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			_, err := kr.add(key)
+			if err != nil {
+				return nil, fmt.Errorf("keyring: %s: %w", file, err)
+			}
+		}
+	}
+
+	return kr, nil
+}
+
+func (kr *Keyring) Sign(claims *jwt.Claims) ([]byte, error) {
+	switch {
+	case kr.rsaKey != nil:
+		claims.KeyID = kr.signingID
+		return claims.RSASign(jwt.RS256, kr.rsaKey)
+	case kr.ed25519Key != nil:
+		claims.KeyID = kr.signingID
+		return claims.EdDSASign(kr.ed25519Key)
+	default:
+		return claims.HMACSign(jwt.HS256, kr.secret)
+	}
+}
+
+func (kr *Keyring) Check(token []byte) (*jwt.Claims, error) {
+	return kr.register.Check(token)
+}
+
+func (kr *Keyring) JWKS() []JWK {
+	return kr.keys
+}
+
+func (kr *Keyring) SigningKeyID() string {
+	return kr.signingID
+}
+
+func (kr *Keyring) add(key interface{}) (string, error) {
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+
+	var jwk JWK
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return "", fmt.Errorf("RSA keys must be at least %d bits", minRSABits)
+		}
+
+		jwk = JWK{
+			KeyType:   "RSA",
+			Algorithm: jwt.RS256,
+			N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		jwk = JWK{
+			KeyType:   "OKP",
+			Algorithm: jwt.EdDSA,
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(pub),
+		}
+	default:
+		return "", fmt.Errorf("unsupported key type %T", key)
+	}
+
+	jwk.Use = "sig"
+	jwk.KeyID = thumbprint(jwk)
+
+	for _, existing := range kr.keys {
+		if existing.KeyID == jwk.KeyID {
+			return jwk.KeyID, nil
+		}
+	}
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		kr.register.RSAs = append(kr.register.RSAs, pub)
+		kr.register.RSAIDs = append(kr.register.RSAIDs, jwk.KeyID)
+	case ed25519.PublicKey:
+		kr.register.EdDSAs = append(kr.register.EdDSAs, pub)
+		kr.register.EdDSAIDs = append(kr.register.EdDSAIDs, jwk.KeyID)
+	}
+
+	kr.keys = append(kr.keys, jwk)
+
+	return jwk.KeyID, nil
+}
+
+func thumbprint(jwk JWK) string {
+	var members interface{}
+
+	switch jwk.KeyType {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+
+	js, _ := json.Marshal(members)
+	sum := sha256.Sum256(js)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func loadPEMFile(file string) ([]interface{}, error) {
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: %w", err)
+	}
+
+	var keys []interface{}
+
+	for {
+		block, rest := pem.Decode(text)
+		if block == nil {
+			break
+		}
+		text = rest
+
+		var key interface{}
+
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		default:
+			return nil, fmt.Errorf("keyring: %s: unsupported PEM block %q", file, block.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("keyring: %s: %w", file, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring: %s: no PEM keys found", file)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,71 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const redactedToken = "[redacted]"
+
+type Log struct {
+	logger       *log.Logger
+	dir          string
+	sender       string
+	exposeTokens bool
+}
+
+func NewLog(logger *log.Logger, dir, sender string, exposeTokens bool) *Log {
+	return &Log{
+		logger:       logger,
+		dir:          dir,
+		sender:       sender,
+		exposeTokens: exposeTokens,
+	}
+}
+
+func (m *Log) Send(recipient, templateFile string, data interface{}) error {
+	if !m.exposeTokens {
+		data = redactTokens(data)
+	}
+
+	msg, err := render(templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	if m.dir == "" {
+		m.logger.Printf("email to %s: %s\n%s", recipient, msg.subject, strings.TrimSpace(msg.plainBody))
+		return nil
+	}
+
+	raw, err := msg.bytes(m.sender, recipient)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), strings.TrimSuffix(templateFile, ".tmpl"))
+
+	return os.WriteFile(filepath.Join(m.dir, name), raw, 0o600)
+}
+
+func redactTokens(data interface{}) interface{} {
+	values, ok := data.(map[string]interface{})
+	if !ok {
+		return data
+	}
+
+	redacted := make(map[string]interface{}, len(values))
+
+	for key, value := range values {
+		if strings.HasSuffix(strings.ToLower(key), "token") {
+			value = redactedToken
+		}
+		redacted[key] = value
+	}
+
+	return redacted
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS members_permissions;
+DROP TABLE IF EXISTS permissions;
\ No newline at end of file
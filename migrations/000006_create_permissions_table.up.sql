@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS permissions (
+    id bigserial PRIMARY KEY,
+    code text UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS members_permissions (
+    member_id bigint NOT NULL REFERENCES members ON DELETE CASCADE,
+    permission_id bigint NOT NULL REFERENCES permissions ON DELETE CASCADE,
+    PRIMARY KEY (member_id, permission_id)
+);
+
+INSERT INTO permissions (code)
+VALUES
+    ('exercises:read'),
+    ('exercises:write'),
+    ('members:admin');
+
+INSERT INTO members_permissions (member_id, permission_id)
+SELECT members.id, permissions.id
+FROM members, permissions
+WHERE members.activated = true AND permissions.code = 'exercises:read';
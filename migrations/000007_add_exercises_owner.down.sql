@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS exercises_owner_member_id_idx;
+ALTER TABLE exercises DROP COLUMN IF EXISTS owner_member_id;
\ No newline at end of file
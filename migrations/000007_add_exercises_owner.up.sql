@@ -0,0 +1,3 @@
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS owner_member_id bigint REFERENCES members(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS exercises_owner_member_id_idx ON exercises (owner_member_id);
@@ -0,0 +1,3 @@
+ALTER TABLE workout_details DROP COLUMN IF EXISTS position;
+ALTER TABLE workouts DROP COLUMN IF EXISTS version;
+ALTER TABLE workouts DROP COLUMN IF EXISTS notes;
\ No newline at end of file
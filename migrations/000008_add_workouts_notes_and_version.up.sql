@@ -0,0 +1,12 @@
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS notes text NOT NULL DEFAULT '';
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS position int NOT NULL DEFAULT 0;
+
+UPDATE workout_details
+SET position = ordered.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY workout_id ORDER BY id) AS position
+    FROM workout_details
+) AS ordered
+WHERE workout_details.id = ordered.id;
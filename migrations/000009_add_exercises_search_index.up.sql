@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS exercises_search_idx ON exercises USING GIN (to_tsvector('english', name || ' ' || coalesce(description, '')));
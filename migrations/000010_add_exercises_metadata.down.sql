@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS exercises_secondary_muscles_idx;
+DROP INDEX IF EXISTS exercises_primary_muscles_idx;
+
+ALTER TABLE exercises DROP COLUMN IF EXISTS measurement_type;
+ALTER TABLE exercises DROP COLUMN IF EXISTS mechanic;
+ALTER TABLE exercises DROP COLUMN IF EXISTS secondary_muscles;
+ALTER TABLE exercises DROP COLUMN IF EXISTS primary_muscles;
+ALTER TABLE exercises DROP COLUMN IF EXISTS equipment;
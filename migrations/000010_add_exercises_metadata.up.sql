@@ -0,0 +1,8 @@
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS equipment text NOT NULL DEFAULT '';
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS primary_muscles text[] NOT NULL DEFAULT '{}';
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS secondary_muscles text[] NOT NULL DEFAULT '{}';
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS mechanic text NOT NULL DEFAULT '';
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS measurement_type text NOT NULL DEFAULT 'weight_reps';
+
+CREATE INDEX IF NOT EXISTS exercises_primary_muscles_idx ON exercises USING GIN (primary_muscles);
+CREATE INDEX IF NOT EXISTS exercises_secondary_muscles_idx ON exercises USING GIN (secondary_muscles);
@@ -0,0 +1,7 @@
+ALTER TABLE workout_details DROP COLUMN IF EXISTS calories;
+ALTER TABLE workout_details DROP COLUMN IF EXISTS heart_rate;
+ALTER TABLE workout_details DROP COLUMN IF EXISTS distance_meters;
+ALTER TABLE workout_details DROP COLUMN IF EXISTS duration_seconds;
+
+ALTER TABLE workout_details ALTER COLUMN weight DROP DEFAULT;
+ALTER TABLE workout_details ALTER COLUMN repetitions DROP DEFAULT;
@@ -0,0 +1,7 @@
+ALTER TABLE workout_details ALTER COLUMN repetitions SET DEFAULT 0;
+ALTER TABLE workout_details ALTER COLUMN weight SET DEFAULT 0;
+
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS duration_seconds int;
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS distance_meters float;
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS heart_rate int;
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS calories int;
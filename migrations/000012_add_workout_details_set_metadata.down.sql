@@ -0,0 +1,9 @@
+ALTER TABLE workout_details DROP CONSTRAINT IF EXISTS workout_details_set_type_check;
+ALTER TABLE workout_details DROP CONSTRAINT IF EXISTS workout_details_rpe_check;
+
+ALTER TABLE workout_details DROP COLUMN IF EXISTS notes;
+ALTER TABLE workout_details DROP COLUMN IF EXISTS set_type;
+ALTER TABLE workout_details DROP COLUMN IF EXISTS rest_seconds;
+ALTER TABLE workout_details DROP COLUMN IF EXISTS tempo;
+ALTER TABLE workout_details DROP COLUMN IF EXISTS rir;
+ALTER TABLE workout_details DROP COLUMN IF EXISTS rpe;
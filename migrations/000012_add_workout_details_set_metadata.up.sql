@@ -0,0 +1,9 @@
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS rpe real;
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS rir int;
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS tempo text NOT NULL DEFAULT '';
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS rest_seconds int;
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS set_type text NOT NULL DEFAULT 'working';
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS notes text NOT NULL DEFAULT '';
+
+ALTER TABLE workout_details ADD CONSTRAINT workout_details_rpe_check CHECK (rpe IS NULL OR (rpe BETWEEN 6 AND 10));
+ALTER TABLE workout_details ADD CONSTRAINT workout_details_set_type_check CHECK (set_type IN ('warm_up', 'working', 'drop_set', 'failure', 'amrap'));
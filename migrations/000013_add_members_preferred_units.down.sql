@@ -0,0 +1,3 @@
+ALTER TABLE members DROP CONSTRAINT IF EXISTS members_preferred_units_check;
+
+ALTER TABLE members DROP COLUMN IF EXISTS preferred_units;
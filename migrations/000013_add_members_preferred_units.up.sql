@@ -0,0 +1,3 @@
+ALTER TABLE members ADD COLUMN IF NOT EXISTS preferred_units text NOT NULL DEFAULT 'metric';
+
+ALTER TABLE members ADD CONSTRAINT members_preferred_units_check CHECK (preferred_units IN ('metric', 'imperial'));
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS personal_records;
\ No newline at end of file
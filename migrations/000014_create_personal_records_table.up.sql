@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS personal_records (
+    id bigserial PRIMARY KEY,
+    member_id bigint NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    exercise_id bigint NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    record_type text NOT NULL,
+    formula text NOT NULL DEFAULT '',
+    value float NOT NULL,
+    weight float,
+    repetitions int,
+    workout_id bigint NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    achieved_at timestamp NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS personal_records_member_exercise_idx ON personal_records (member_id, exercise_id, record_type);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS routine_exercises;
+DROP TABLE IF EXISTS routines;
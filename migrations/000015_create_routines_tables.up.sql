@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS routines (
+    id bigserial PRIMARY KEY,
+    member_id bigint NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    notes text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS routines_member_id_idx ON routines (member_id);
+
+CREATE TABLE IF NOT EXISTS routine_exercises (
+    id bigserial PRIMARY KEY,
+    routine_id bigint NOT NULL REFERENCES routines(id) ON DELETE CASCADE,
+    exercise_id bigint NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    target_sets integer NOT NULL,
+    target_repetitions integer NOT NULL DEFAULT 0,
+    target_weight float NOT NULL DEFAULT 0,
+    target_duration_seconds integer,
+    target_distance_meters float,
+    notes text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS routine_exercises_routine_id_idx ON routine_exercises (routine_id, position);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS programs (
+    id bigserial PRIMARY KEY,
+    member_id bigint NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    progression text NOT NULL DEFAULT 'none' CHECK (progression IN ('none', 'linear', 'percentage')),
+    increment float NOT NULL DEFAULT 2.5,
+    deload_percentage float NOT NULL DEFAULT 60,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS programs_member_id_idx ON programs (member_id);
+
+CREATE TABLE IF NOT EXISTS program_days (
+    id bigserial PRIMARY KEY,
+    program_id bigint NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    week integer NOT NULL,
+    day integer NOT NULL,
+    routine_id bigint NOT NULL REFERENCES routines(id) ON DELETE CASCADE,
+    deload boolean NOT NULL DEFAULT false,
+    UNIQUE (program_id, week, day)
+);
+
+CREATE TABLE IF NOT EXISTS program_enrollments (
+    member_id bigint PRIMARY KEY REFERENCES members(id) ON DELETE CASCADE,
+    program_id bigint NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    started_at timestamp NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
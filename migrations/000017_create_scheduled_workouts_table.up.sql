@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS scheduled_workouts (
+    id bigserial PRIMARY KEY,
+    member_id bigint NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    date date NOT NULL,
+    title text NOT NULL DEFAULT '',
+    notes text NOT NULL DEFAULT '',
+    routine_id bigint REFERENCES routines(id) ON DELETE SET NULL,
+    status text NOT NULL DEFAULT 'planned' CHECK (status IN ('planned', 'completed', 'skipped')),
+    workout_id bigint REFERENCES workouts(id) ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_workouts_member_date_idx ON scheduled_workouts (member_id, date);
+CREATE INDEX IF NOT EXISTS scheduled_workouts_workout_id_idx ON scheduled_workouts (workout_id);
@@ -0,0 +1,3 @@
+ALTER TABLE members ALTER COLUMN weight TYPE int USING round(weight);
+
+DROP TABLE IF EXISTS body_measurements;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS body_measurements (
+    id bigserial PRIMARY KEY,
+    member_id bigint NOT NULL REFERENCES members(id) ON DELETE CASCADE,
+    date date NOT NULL,
+    weight float,
+    body_fat_percentage float,
+    neck float,
+    chest float,
+    waist float,
+    hips float,
+    arms float,
+    thighs float,
+    calves float,
+    notes text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    UNIQUE (member_id, date)
+);
+
+ALTER TABLE members ALTER COLUMN weight TYPE float;
+
+INSERT INTO body_measurements (member_id, date, weight)
+SELECT id, created_at::date, weight
+FROM members
+WHERE weight IS NOT NULL AND weight > 0;
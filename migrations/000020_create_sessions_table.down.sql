@@ -0,0 +1,5 @@
+DELETE FROM tokens WHERE scope = 'refresh';
+
+ALTER TABLE tokens DROP COLUMN IF EXISTS session_id;
+
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1 @@
+-- Backfilled records cannot be told apart from detected ones, so they are kept.
@@ -0,0 +1,66 @@
+WITH sets AS (
+    SELECT w.member_id, wd.exercise_id, w.id AS workout_id, w.date, wd.position, wd.weight, wd.repetitions
+    FROM workout_details wd
+    JOIN workouts w ON w.id = wd.workout_id
+    JOIN exercises e ON e.id = wd.exercise_id
+    WHERE e.measurement_type = 'weight_reps'
+    AND wd.set_type <> 'warm_up'
+    AND wd.weight > 0
+    AND wd.repetitions > 0
+),
+estimates AS (
+    SELECT sets.*, 'epley' AS formula,
+        CASE WHEN repetitions <= 1 THEN weight ELSE weight * (1 + repetitions / 30.0) END AS value
+    FROM sets
+    UNION ALL
+    SELECT sets.*, 'brzycki' AS formula,
+        CASE WHEN repetitions <= 1 THEN weight ELSE weight * 36 / (37 - LEAST(repetitions, 36)) END AS value
+    FROM sets
+),
+volumes AS (
+    SELECT member_id, exercise_id, workout_id, date, SUM(weight * repetitions) AS value
+    FROM sets
+    GROUP BY member_id, exercise_id, workout_id, date
+),
+candidates AS (
+    (
+        SELECT DISTINCT ON (member_id, exercise_id)
+            member_id, exercise_id, 'max_weight' AS record_type, '' AS formula, weight AS value, weight, repetitions, workout_id, date
+        FROM sets
+        ORDER BY member_id, exercise_id, weight DESC, date, workout_id, position
+    )
+    UNION ALL
+    (
+        SELECT DISTINCT ON (member_id, exercise_id, weight)
+            member_id, exercise_id, 'max_reps_at_weight', '', repetitions::float, weight, repetitions, workout_id, date
+        FROM sets
+        ORDER BY member_id, exercise_id, weight, repetitions DESC, date, workout_id, position
+    )
+    UNION ALL
+    (
+        SELECT DISTINCT ON (member_id, exercise_id, formula)
+            member_id, exercise_id, 'estimated_1rm', formula, value, weight, repetitions, workout_id, date
+        FROM estimates
+        ORDER BY member_id, exercise_id, formula, value DESC, date, workout_id, position
+    )
+    UNION ALL
+    (
+        SELECT DISTINCT ON (member_id, exercise_id)
+            member_id, exercise_id, 'max_session_volume', '', value, NULL::float, NULL::int, workout_id, date
+        FROM volumes
+        ORDER BY member_id, exercise_id, value DESC, date, workout_id
+    )
+)
+INSERT INTO personal_records (member_id, exercise_id, record_type, formula, value, weight, repetitions, workout_id, achieved_at)
+SELECT c.member_id, c.exercise_id, c.record_type, c.formula, c.value, c.weight, c.repetitions, c.workout_id, c.date
+FROM candidates c
+WHERE NOT EXISTS (
+    SELECT 1
+    FROM personal_records pr
+    WHERE pr.member_id = c.member_id
+    AND pr.exercise_id = c.exercise_id
+    AND pr.record_type = c.record_type
+    AND pr.formula = c.formula
+    AND (c.record_type <> 'max_reps_at_weight' OR pr.weight = c.weight)
+    AND pr.value >= c.value
+);
@@ -0,0 +1 @@
+ALTER TABLE workout_details DROP COLUMN IF EXISTS planned;
@@ -0,0 +1 @@
+ALTER TABLE workout_details ADD COLUMN IF NOT EXISTS planned boolean NOT NULL DEFAULT false;
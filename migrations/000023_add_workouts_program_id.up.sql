@@ -0,0 +1,6 @@
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS program_id bigint REFERENCES programs(id) ON DELETE SET NULL;
+
+UPDATE workouts w
+SET program_id = pe.program_id
+FROM program_enrollments pe
+WHERE pe.member_id = w.member_id AND w.date >= pe.started_at;